@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/redis/go-redis/v9"
 	logger "github.com/sirupsen/logrus"
 
 	_ "simple_crud_go/docs"
 
 	"simple_crud_go/configs"
 	"simple_crud_go/internal/db"
+	"simple_crud_go/internal/grpcserver"
 	"simple_crud_go/internal/handler"
+	"simple_crud_go/internal/middleware"
 	"simple_crud_go/internal/repository"
 	"simple_crud_go/internal/service"
+	"simple_crud_go/pkg/blob"
+	"simple_crud_go/pkg/cache"
+	"simple_crud_go/pkg/lifecycle"
 	"simple_crud_go/pkg/logging"
+	"simple_crud_go/pkg/ratelimit"
 	"simple_crud_go/pkg/server"
+	"simple_crud_go/pkg/utils"
 )
 
 // @title           User Management API
@@ -19,30 +33,175 @@ import (
 // @description     API для управления пользователями: создание, обновление, удаление и получение данных.
 
 // @host      localhost:8000
-// @BasePath  /user
+// @BasePath  /api/v1
 func main() {
+	if err := run(); err != nil {
+		logger.Errorf("Application stopped with error: %v", err)
+		os.Exit(1)
+	}
+}
+
+// run запускает приложение и блокируется до его остановки.
+// Ошибки возвращаются, а не завершают процесс, чтобы отработали отложенные вызовы.
+func run() error {
 	// Загружаем конфигурацию
 	cfg, err := configs.LoadConfig("./configs")
 	if err != nil {
-		logger.Fatalf("Error loading config: %v", err)
+		return fmt.Errorf("error loading config: %w", err)
 	}
 
 	// Настройка логгера
 	logging.SetupLogger(&cfg.Logging)
+	defer logging.Close()
+
+	// Компоненты останавливаются в порядке, обратном регистрации
+	lc := lifecycle.New(cfg.Server.DrainPeriod, cfg.Server.ShutdownTimeout)
 
 	// Подключение к базе данных
 	dbConn, err := db.ConnectPostgres(&cfg.Database)
 	if err != nil {
-		logger.Fatalf("Database connection failed: %v", err)
+		return fmt.Errorf("database connection failed: %w", err)
 	}
-	defer dbConn.Close()
+	lc.OnStop("database", func(ctx context.Context) error {
+		dbConn.Close()
+		return nil
+	})
 
-	db.ApplyMigrations()
+	if err := db.ApplyMigrations(&cfg.Database); err != nil {
+		lc.Shutdown()
+		return err
+	}
+
+	// Включенный RLS не должен молча обходиться ролью, которой принадлежат таблицы
+	if cfg.Database.RowLevelSecurity {
+		if err := db.CheckRowLevelSecurity(context.Background(), dbConn); err != nil {
+			lc.Shutdown()
+			return fmt.Errorf("database role %q: %w", cfg.Database.User, err)
+		}
+	}
 
-	repo := repository.NewUserRepository(dbConn)
-	services := service.NewService(repo)
-	handlers := handler.NewHandler(services)
+	// Реплики для чтения с проверкой доступности
+	replicas, err := db.ConnectReplicas(&cfg.Database)
+	if err != nil {
+		lc.Shutdown()
+		return fmt.Errorf("read replica configuration failed: %w", err)
+	}
+	cluster := db.NewCluster(dbConn, replicas, cfg.Database.ReadYourWrites)
+	lc.OnStop("read replicas", func(ctx context.Context) error {
+		cluster.Close()
+		return nil
+	})
+	lc.Go("replica health check", func(ctx context.Context) error {
+		return cluster.RunHealthChecks(ctx, cfg.Database.ReplicaCheckPeriod, cfg.Database.ConnectTimeout)
+	})
+
+	repo := repository.NewUserRepository(cluster, cfg.Database.QueryTimeout)
+
+	// Кэш пользователей в памяти или в Redis
+	if cfg.Cache.Enabled {
+		var cacheStore cache.Store = cache.NewMemoryStore(cfg.Cache.MaxEntries)
+		if cfg.Cache.Backend == "redis" {
+			redisClient := redis.NewClient(&redis.Options{
+				Addr:     cfg.Cache.Redis.Addr,
+				Password: cfg.Cache.Redis.Password,
+				DB:       cfg.Cache.Redis.DB,
+			})
+			lc.OnStop("redis", func(ctx context.Context) error {
+				return redisClient.Close()
+			})
+			cacheStore = cache.NewRedisStore(redisClient, cfg.Cache.Redis.KeyPrefix)
+		}
+		repo = repository.NewCachedUserRepository(repo, cacheStore, cfg.Cache.TTL)
+	}
+	attempts := repository.NewLoginAttemptRepository(dbConn, cfg.Database.QueryTimeout)
+
+	// Политика паролей со списком утекших паролей
+	var breached service.BreachedPasswordChecker
+	if cfg.PasswordPolicy.BreachedListFile != "" {
+		breached, err = service.LoadBreachedPasswords(cfg.PasswordPolicy.BreachedListFile)
+		if err != nil {
+			lc.Shutdown()
+			return fmt.Errorf("could not load breached passwords list: %w", err)
+		}
+	}
+	policy := service.NewPasswordPolicy(&cfg.PasswordPolicy, breached)
+
+	// Новые хэши создаются выбранным алгоритмом, старые обновляются при входе
+	hasher, err := utils.NewPasswordHasher(&cfg.PasswordHashing)
+	if err != nil {
+		lc.Shutdown()
+		return fmt.Errorf("could not create password hasher: %w", err)
+	}
+
+	// Секреты TOTP хранятся зашифрованными
+	secretBox, err := utils.NewSecretBox(cfg.Auth.TOTP.EncryptionKey)
+	if err != nil {
+		lc.Shutdown()
+		return fmt.Errorf("could not create TOTP secret encryptor: %w", err)
+	}
+	totpService := service.NewTOTPService(repository.NewTOTPRepository(dbConn, cfg.Database.QueryTimeout), repo, secretBox, &cfg.Auth.TOTP)
+
+	sessions := repository.NewSessionRepository(dbConn, cfg.Database.QueryTimeout)
+
+	// Хранилище файлов для аватаров
+	blobStore, err := blob.NewLocalStore(cfg.BlobStore.Local.Dir, cfg.BlobStore.Local.BaseURL)
+	if err != nil {
+		lc.Shutdown()
+		return fmt.Errorf("could not create blob store: %w", err)
+	}
+
+	services := &service.Services{
+		UserService:    service.NewService(repo, policy, hasher, blobStore),
+		AvatarService:  service.NewAvatarService(repo, blobStore, &cfg.Avatar),
+		AuthService:    service.NewAuthService(repo, attempts, sessions, totpService, hasher, &cfg.Auth),
+		TOTPService:    totpService,
+		APIKeyService:  service.NewAPIKeyService(repository.NewAPIKeyRepository(dbConn, cfg.Database.QueryTimeout)),
+		OIDCService:    service.NewOIDCService(repository.NewOIDCRepository(dbConn, cfg.Database.QueryTimeout), sessions, hasher, &cfg.Auth),
+		SessionService: service.NewSessionService(sessions),
+	}
+
+	// Ограничение частоты запросов, в памяти или общее для всех реплик в PostgreSQL
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Backend == "postgres" {
+		limitStore = repository.NewRateLimitRepository(dbConn, cfg.Database.QueryTimeout)
+	}
+	limiter := middleware.NewRateLimiter(limitStore, &cfg.RateLimit)
+	lc.Go("rate limit cleanup", func(ctx context.Context) error {
+		return ratelimit.RunCleanup(ctx, limitStore, cfg.RateLimit.CleanupInterval, cfg.RateLimit.BucketTTL)
+	})
+
+	handlers := handler.NewHandler(services, cfg, lc, limiter)
+
+	// Горячая перезагрузка конфигурации при изменении файла и по SIGHUP
+	watcher := configs.NewWatcher("./configs", cfg)
+	logCfg := cfg.Logging
+	watcher.Subscribe(func(cfg *configs.Config) {
+		logging.ApplyConfig(&logCfg, &cfg.Logging)
+		logCfg = cfg.Logging
+		limiter.Update(&cfg.RateLimit)
+	})
+	watcher.Watch()
+	lc.Go("config reload", func(ctx context.Context) error {
+		return server.WatchReloadSignal(ctx, watcher.Reload)
+	})
 
 	// Настройка и запуск сервера
-	server.SetupAndRunServer(&cfg.Server, handlers.InitRouters())
+	srv, err := server.NewServer(&cfg.Server, handlers.InitRouters())
+	if err != nil {
+		lc.Shutdown()
+		return fmt.Errorf("could not configure server: %w", err)
+	}
+	lc.Serve("http server", srv.ListenAndServe, srv.Shutdown)
+
+	// gRPC API поверх тех же сервисов
+	if cfg.GRPC.Enabled {
+		grpcSrv := grpcserver.NewServer(&cfg.GRPC, services, limiter)
+		lc.Serve("grpc server", grpcSrv.ListenAndServe, grpcSrv.Shutdown)
+	}
+
+	// Ожидаем сигнал завершения и останавливаем компоненты
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return lc.Wait(ctx)
 }
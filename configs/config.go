@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -13,37 +14,272 @@ import (
 // Конфигурация сервера
 type ServerConfig struct {
 	Host           string        `mapstructure:"host"`
-	Port           int           `mapstructure:"port"`
-	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
-	MaxHeaderBytes int           `mapstructure:"max_header_bytes"`
+	Port           int           `mapstructure:"port" validate:"required,min=1,max=65535"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout" validate:"gte=0"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout" validate:"gte=0"`
+	MaxHeaderBytes int           `mapstructure:"max_header_bytes" validate:"gte=0"`
+
+	// Остановка: сколько отдавать "не готов" перед остановкой и сколько ждать завершения запросов
+	DrainPeriod     time.Duration `mapstructure:"drain_period" validate:"gte=0"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"gte=0"`
+
+	TLS TLSConfig `mapstructure:"tls"`
+	H2C bool      `mapstructure:"h2c"`
+
+	// Адрес клиента берется из заголовков remote_ip_headers, только если соединение пришло от прокси
+	// из trusted_proxies. Пустой trusted_proxies - заголовки игнорируются, используется адрес соединения
+	TrustedProxies  []string `mapstructure:"trusted_proxies" validate:"dive,cidr|ip"`
+	RemoteIPHeaders []string `mapstructure:"remote_ip_headers"`
+}
+
+// Конфигурация gRPC-сервера. Сервер работает без TLS и рассчитан на внутреннюю сеть
+type GRPCConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port" validate:"min=1,max=65535"`
+}
+
+// Версионирование API. Маршруты без префикса /api/v1 - устаревший псевдоним v1
+type APIConfig struct {
+	LegacyRoutes bool   `mapstructure:"legacy_routes"`
+	LegacySunset string `mapstructure:"legacy_sunset" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// HTTP-кэширование ресурсов пользователей. cache_control передается в одноименном заголовке ответов
+type HTTPCacheConfig struct {
+	CacheControl string `mapstructure:"cache_control"`
+}
+
+// Конфигурация TLS сервера. TLS включается, если заданы сертификат и ключ
+type TLSConfig struct {
+	CertFile     string `mapstructure:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile      string `mapstructure:"key_file" validate:"required_with=CertFile"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	ClientAuth   string `mapstructure:"client_auth" validate:"omitempty,oneof=none request verify_if_given require"`
+	MinVersion   string `mapstructure:"min_version" validate:"omitempty,oneof=1.2 1.3"`
+	CipherPolicy string `mapstructure:"cipher_policy" validate:"omitempty,oneof=default intermediate modern"`
+}
+
+// Enabled сообщает, настроен ли TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
 }
 
 // Конфигурация логирования
 type LoggerConfig struct {
-	Level      string `mapstructure:"level"`
-	Format     string `mapstructure:"format"`
-	OutputFile string `mapstructure:"output_file"`
+	Level      string            `mapstructure:"level" validate:"required,oneof=trace debug info warn warning error fatal panic"`
+	Format     string            `mapstructure:"format" validate:"required,oneof=text json"`
+	OutputFile string            `mapstructure:"output_file"`
+	Console    ConsoleSinkConfig `mapstructure:"console"`
+	File       LogSinkConfig     `mapstructure:"file"`
+	Rotation   LogRotationConfig `mapstructure:"rotation"`
+}
+
+// Настройки отдельного приемника логов. Пустые значения наследуются от LoggerConfig
+type LogSinkConfig struct {
+	Level  string `mapstructure:"level" validate:"omitempty,oneof=trace debug info warn warning error fatal panic"`
+	Format string `mapstructure:"format" validate:"omitempty,oneof=text json"`
+}
+
+// Настройки вывода логов в консоль
+type ConsoleSinkConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	LogSinkConfig `mapstructure:",squash"`
+}
+
+// Настройки ротации файла логов
+type LogRotationConfig struct {
+	MaxSizeMB  int           `mapstructure:"max_size_mb" validate:"gte=0"`
+	MaxAgeDays int           `mapstructure:"max_age_days" validate:"gte=0"`
+	MaxBackups int           `mapstructure:"max_backups" validate:"gte=0"`
+	Compress   bool          `mapstructure:"compress"`
+	Interval   time.Duration `mapstructure:"interval" validate:"gte=0"`
 }
 
 // Конфигурация базы данных
 type PostgresConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
-	SSLMode  string `mapstructure:"sslmode"`
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"required,min=1,max=65535"`
+	User     string `mapstructure:"user" validate:"required"`
+	Password string `mapstructure:"password" validate:"required"`
+	DBName   string `mapstructure:"dbname" validate:"required"`
+	SSLMode  string `mapstructure:"sslmode" validate:"required,oneof=disable allow prefer require verify-ca verify-full"`
+
+	// Настройки пула соединений
+	MaxConns          int32         `mapstructure:"max_conns" validate:"gte=1"`
+	MinConns          int32         `mapstructure:"min_conns" validate:"gte=0,ltefield=MaxConns"`
+	MaxConnLifetime   time.Duration `mapstructure:"max_conn_lifetime" validate:"gte=0"`
+	MaxConnIdleTime   time.Duration `mapstructure:"max_conn_idle_time" validate:"gte=0"`
+	HealthCheckPeriod time.Duration `mapstructure:"health_check_period" validate:"gte=0"`
+
+	// Таймауты
+	ConnectTimeout      time.Duration `mapstructure:"connect_timeout" validate:"gte=0"`
+	ConnectRetryTimeout time.Duration `mapstructure:"connect_retry_timeout" validate:"gte=0"`
+	StatementTimeout    time.Duration `mapstructure:"statement_timeout" validate:"gte=0"`
+	QueryTimeout        time.Duration `mapstructure:"query_timeout" validate:"gte=0"`
+
+	ApplicationName string `mapstructure:"application_name"`
+
+	// Реплики для чтения, остальные настройки подключения берутся у основной базы
+	Replicas           []ReplicaConfig `mapstructure:"replicas" validate:"dive"`
+	ReplicaCheckPeriod time.Duration   `mapstructure:"replica_check_period" validate:"gte=0"`
+	ReadYourWrites     bool            `mapstructure:"read_your_writes"`
+	RowLevelSecurity   bool            `mapstructure:"row_level_security"`
+}
+
+// Реплика базы данных для чтения
+type ReplicaConfig struct {
+	Host string `mapstructure:"host" validate:"required"`
+	Port int    `mapstructure:"port" validate:"required,min=1,max=65535"`
+}
+
+// Кэш пользователей для GetUserById. Кэш в памяти у каждой реплики свой, redis - общий для всех реплик
+type CacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Backend    string        `mapstructure:"backend" validate:"omitempty,oneof=memory redis"`
+	TTL        time.Duration `mapstructure:"ttl" validate:"gte=0"`
+	MaxEntries int           `mapstructure:"max_entries" validate:"gte=0"`
+	Redis      RedisConfig   `mapstructure:"redis"`
+}
+
+// Подключение к Redis или совместимому серверу
+type RedisConfig struct {
+	Addr      string `mapstructure:"addr"`
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db" validate:"gte=0"`
+	KeyPrefix string `mapstructure:"key_prefix"`
+}
+
+// Аватары пользователей. Исходное изображение и миниатюры сохраняются в хранилище файлов
+type AvatarConfig struct {
+	MaxBytes       int64 `mapstructure:"max_bytes" validate:"gt=0"`
+	MaxDimension   int   `mapstructure:"max_dimension" validate:"gt=0"`
+	ThumbnailSizes []int `mapstructure:"thumbnail_sizes" validate:"dive,min=16,max=1024"`
+}
+
+// Хранилище файлов. local - каталог на диске, файлы раздаются самим сервером по base_url
+type BlobStoreConfig struct {
+	Backend string          `mapstructure:"backend" validate:"oneof=local"`
+	Local   LocalBlobConfig `mapstructure:"local"`
+}
+
+// Локальное хранилище файлов
+type LocalBlobConfig struct {
+	Dir     string `mapstructure:"dir" validate:"required"`
+	BaseURL string `mapstructure:"base_url" validate:"required,startswith=/"`
+}
+
+// Конфигурация ограничения частоты запросов
+type RateLimitConfig struct {
+	Enabled         bool                     `mapstructure:"enabled"`
+	Backend         string                   `mapstructure:"backend" validate:"omitempty,oneof=memory postgres"`
+	CleanupInterval time.Duration            `mapstructure:"cleanup_interval" validate:"gte=0"`
+	BucketTTL       time.Duration            `mapstructure:"bucket_ttl" validate:"gte=0"`
+	Rules           map[string]RateLimitRule `mapstructure:"rules" validate:"dive"`
+}
+
+// Правило ограничения для группы маршрутов: requests запросов за period с запасом burst
+type RateLimitRule struct {
+	Requests int           `mapstructure:"requests" validate:"required,min=1"`
+	Period   time.Duration `mapstructure:"period" validate:"required,gt=0"`
+	Burst    int           `mapstructure:"burst" validate:"gte=0"`
+	Key      string        `mapstructure:"key" validate:"omitempty,oneof=ip user"`
+}
+
+// Конфигурация аутентификации
+type AuthConfig struct {
+	JWTSecret string        `mapstructure:"jwt_secret" validate:"required,min=32,not_default_secret"`
+	TokenTTL  time.Duration `mapstructure:"token_ttl" validate:"gt=0"`
+	Lockout   LockoutConfig `mapstructure:"lockout"`
+	TOTP      TOTPConfig    `mapstructure:"totp"`
+	OIDC      OIDCConfig    `mapstructure:"oidc"`
+}
+
+// Вход через внешних провайдеров OpenID Connect. Ключ в providers - имя провайдера в URL
+type OIDCConfig struct {
+	StateTTL  time.Duration                 `mapstructure:"state_ttl" validate:"gt=0"`
+	Providers map[string]OIDCProviderConfig `mapstructure:"providers" validate:"dive"`
+}
+
+// Провайдер OpenID Connect, настройки берутся из discovery-документа issuer_url
+type OIDCProviderConfig struct {
+	IssuerURL    string   `mapstructure:"issuer_url" validate:"required,url"`
+	ClientID     string   `mapstructure:"client_id" validate:"required"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url" validate:"required,url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// Двухфакторная аутентификация TOTP. Секреты хранятся зашифрованными ключом encryption_key (32 байта в base64)
+type TOTPConfig struct {
+	Issuer        string `mapstructure:"issuer" validate:"required"`
+	EncryptionKey string `mapstructure:"encryption_key" validate:"required,base64,not_default_secret"`
+	Skew          uint   `mapstructure:"skew" validate:"max=3"`
+	RecoveryCodes int    `mapstructure:"recovery_codes" validate:"min=1,max=50"`
+}
+
+// Блокировка входа после серии неудачных попыток. Длительность удваивается с каждой блокировкой
+type LockoutConfig struct {
+	MaxFailures   int           `mapstructure:"max_failures" validate:"min=1"`
+	FailureWindow time.Duration `mapstructure:"failure_window" validate:"gt=0"`
+	BaseDuration  time.Duration `mapstructure:"base_duration" validate:"gt=0"`
+	MaxDuration   time.Duration `mapstructure:"max_duration" validate:"gtefield=BaseDuration"`
+}
+
+// Политика паролей. max_length задается в байтах и не может превышать 72 (ограничение bcrypt)
+type PasswordPolicyConfig struct {
+	MinLength        int    `mapstructure:"min_length" validate:"min=1"`
+	MaxLength        int    `mapstructure:"max_length" validate:"gtefield=MinLength,max=72"`
+	RequireUpper     bool   `mapstructure:"require_upper"`
+	RequireLower     bool   `mapstructure:"require_lower"`
+	RequireDigit     bool   `mapstructure:"require_digit"`
+	RequireSpecial   bool   `mapstructure:"require_special"`
+	RejectUserInfo   bool   `mapstructure:"reject_user_info"`
+	BreachedListFile string `mapstructure:"breached_list_file"`
+}
+
+// Хэширование паролей. Хэши с устаревшими параметрами обновляются при входе пользователя
+type PasswordHashingConfig struct {
+	Algorithm  string       `mapstructure:"algorithm" validate:"oneof=bcrypt argon2id"`
+	BcryptCost int          `mapstructure:"bcrypt_cost" validate:"min=4,max=31"`
+	Argon2     Argon2Config `mapstructure:"argon2"`
+}
+
+// Параметры argon2id. memory задается в КиБ
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory" validate:"min=8192"`
+	Iterations  uint32 `mapstructure:"iterations" validate:"min=1"`
+	Parallelism uint8  `mapstructure:"parallelism" validate:"min=1"`
+	SaltLength  uint32 `mapstructure:"salt_length" validate:"min=8"`
+	KeyLength   uint32 `mapstructure:"key_length" validate:"min=16"`
+}
+
+// Конфигурация административного доступа
+type AdminConfig struct {
+	Token              string   `mapstructure:"token" validate:"omitempty,min=16"`
+	ClientCertSubjects []string `mapstructure:"client_cert_subjects"`
 }
 
 // Полная конфигурация
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Logging  LoggerConfig   `mapstructure:"logging"`
-	Database PostgresConfig `mapstructure:"database"`
+	Server          ServerConfig          `mapstructure:"server"`
+	GRPC            GRPCConfig            `mapstructure:"grpc"`
+	API             APIConfig             `mapstructure:"api"`
+	HTTPCache       HTTPCacheConfig       `mapstructure:"http_cache"`
+	Logging         LoggerConfig          `mapstructure:"logging"`
+	Database        PostgresConfig        `mapstructure:"database"`
+	Admin           AdminConfig           `mapstructure:"admin"`
+	RateLimit       RateLimitConfig       `mapstructure:"rate_limit"`
+	Cache           CacheConfig           `mapstructure:"cache"`
+	Avatar          AvatarConfig          `mapstructure:"avatar"`
+	BlobStore       BlobStoreConfig       `mapstructure:"blob_store"`
+	Auth            AuthConfig            `mapstructure:"auth"`
+	PasswordPolicy  PasswordPolicyConfig  `mapstructure:"password_policy"`
+	PasswordHashing PasswordHashingConfig `mapstructure:"password_hashing"`
 }
 
-// LoadConfig загружает конфигурацию из файлов и переменных окружения
+// LoadConfig загружает конфигурацию из файлов и переменных окружения.
+// Возвращает ошибку со списком всех найденных проблем конфигурации.
 func LoadConfig(path string) (*Config, error) {
 	// Загружаем переменные окружения из файла .env
 	if err := godotenv.Load(".env"); err != nil {
@@ -51,33 +287,182 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	// Инициализация Viper
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(path)
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v := newViper(path)
 
 	// Чтение конфигурации
-	if err := viper.ReadInConfig(); err != nil {
-		log.Printf("Warning: Could not load YAML config file: %v", err)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not load YAML config file: %w", err)
 	}
 
-	// Маппинг данных в структуру Config
+	// Маппинг данных в структуру Config, неизвестные ключи считаются ошибкой.
+	// Ошибки маппинга не прерывают загрузку, чтобы сообщить о них вместе с ошибками валидации.
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, err
+	var problems []string
+	if err := v.UnmarshalExact(&config); err != nil {
+		problems = append(problems, decodeProblems(err)...)
 	}
 
+	// Значения по умолчанию
+	config.applyDefaults()
+
 	// Валидация конфигурации
-	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		return nil, fmt.Errorf("invalid server port: %d", config.Server.Port)
+	problems = append(problems, validateStruct(&config)...)
+	if len(problems) > 0 {
+		return nil, problemsError(problems)
+	}
+
+	return &config, nil
+}
+
+// applyDefaults заполняет незаданные значения значениями по умолчанию
+func (c *Config) applyDefaults() {
+	if c.GRPC.Port == 0 {
+		c.GRPC.Port = 9090
+	}
+	if c.Server.ReadTimeout <= 0 {
+		c.Server.ReadTimeout = 5 * time.Second
 	}
-	if config.Server.ReadTimeout <= 0 {
-		config.Server.ReadTimeout = 5 * time.Second
+	if c.Server.WriteTimeout <= 0 {
+		c.Server.WriteTimeout = 10 * time.Second
 	}
-	if config.Server.WriteTimeout <= 0 {
-		config.Server.WriteTimeout = 10 * time.Second
+	if c.Server.ShutdownTimeout <= 0 {
+		c.Server.ShutdownTimeout = 10 * time.Second
 	}
 
-	return &config, nil
+	if c.Database.MaxConns == 0 {
+		c.Database.MaxConns = 50
+	}
+	if c.Database.HealthCheckPeriod == 0 {
+		c.Database.HealthCheckPeriod = time.Minute
+	}
+	if c.Database.ConnectTimeout == 0 {
+		c.Database.ConnectTimeout = 5 * time.Second
+	}
+	if c.Database.ReplicaCheckPeriod == 0 {
+		c.Database.ReplicaCheckPeriod = 5 * time.Second
+	}
+
+	if c.Cache.Backend == "" {
+		c.Cache.Backend = "memory"
+	}
+	if c.Cache.TTL == 0 {
+		c.Cache.TTL = time.Minute
+	}
+	if c.Cache.MaxEntries == 0 {
+		c.Cache.MaxEntries = 10000
+	}
+	if c.Cache.Redis.Addr == "" {
+		c.Cache.Redis.Addr = "localhost:6379"
+	}
+
+	if c.Avatar.MaxBytes == 0 {
+		c.Avatar.MaxBytes = 5 << 20
+	}
+	if c.Avatar.MaxDimension == 0 {
+		c.Avatar.MaxDimension = 4096
+	}
+	if c.Avatar.ThumbnailSizes == nil {
+		c.Avatar.ThumbnailSizes = []int{64, 256}
+	}
+	if c.BlobStore.Backend == "" {
+		c.BlobStore.Backend = "local"
+	}
+	if c.BlobStore.Local.Dir == "" {
+		c.BlobStore.Local.Dir = "./data/blobs"
+	}
+	if c.BlobStore.Local.BaseURL == "" {
+		c.BlobStore.Local.BaseURL = "/media"
+	}
+
+	if c.Auth.TokenTTL == 0 {
+		c.Auth.TokenTTL = 15 * time.Minute
+	}
+	if c.Auth.Lockout.MaxFailures == 0 {
+		c.Auth.Lockout.MaxFailures = 5
+	}
+	if c.Auth.Lockout.FailureWindow == 0 {
+		c.Auth.Lockout.FailureWindow = 15 * time.Minute
+	}
+	if c.Auth.Lockout.BaseDuration == 0 {
+		c.Auth.Lockout.BaseDuration = time.Minute
+	}
+	if c.Auth.Lockout.MaxDuration == 0 {
+		c.Auth.Lockout.MaxDuration = time.Hour
+	}
+	if c.Auth.TOTP.Issuer == "" {
+		c.Auth.TOTP.Issuer = "simple_crud_go"
+	}
+	if c.Auth.TOTP.RecoveryCodes == 0 {
+		c.Auth.TOTP.RecoveryCodes = 10
+	}
+	if c.Auth.OIDC.StateTTL == 0 {
+		c.Auth.OIDC.StateTTL = 10 * time.Minute
+	}
+
+	if c.PasswordPolicy.MinLength == 0 {
+		c.PasswordPolicy.MinLength = 8
+	}
+	if c.PasswordPolicy.MaxLength == 0 {
+		c.PasswordPolicy.MaxLength = 72
+	}
+
+	if c.PasswordHashing.Algorithm == "" {
+		c.PasswordHashing.Algorithm = "bcrypt"
+	}
+	if c.PasswordHashing.BcryptCost == 0 {
+		c.PasswordHashing.BcryptCost = 10
+	}
+	if c.PasswordHashing.Argon2.Memory == 0 {
+		c.PasswordHashing.Argon2.Memory = 64 * 1024
+	}
+	if c.PasswordHashing.Argon2.Iterations == 0 {
+		c.PasswordHashing.Argon2.Iterations = 3
+	}
+	if c.PasswordHashing.Argon2.Parallelism == 0 {
+		c.PasswordHashing.Argon2.Parallelism = 2
+	}
+	if c.PasswordHashing.Argon2.SaltLength == 0 {
+		c.PasswordHashing.Argon2.SaltLength = 16
+	}
+	if c.PasswordHashing.Argon2.KeyLength == 0 {
+		c.PasswordHashing.Argon2.KeyLength = 32
+	}
+
+	if c.RateLimit.Backend == "" {
+		c.RateLimit.Backend = "memory"
+	}
+	if c.RateLimit.CleanupInterval == 0 {
+		c.RateLimit.CleanupInterval = time.Minute
+	}
+	if c.RateLimit.BucketTTL == 0 {
+		c.RateLimit.BucketTTL = time.Hour
+	}
+}
+
+// Validate проверяет конфигурацию целиком и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	problems := validateStruct(c)
+	if len(problems) == 0 {
+		return nil
+	}
+	return problemsError(problems)
+}
+
+// problemsError собирает список проблем конфигурации в одну ошибку
+func problemsError(problems []string) error {
+	errs := make([]error, 0, len(problems))
+	for _, problem := range problems {
+		errs = append(errs, errors.New(problem))
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
+
+func newViper(path string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(path)
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	return v
 }
@@ -0,0 +1,106 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600)
+	require.NoError(t, err)
+	return dir
+}
+
+func TestLoadConfig_ListsAllProblems(t *testing.T) {
+	dir := writeConfig(t, `
+server:
+  port: 70000
+  trusted_proxies: ["10.0.0.0/8", "proxy.local"]
+logging:
+  level: "debug"
+  format: "xml"
+database:
+  host: "localhost"
+  port: 5432
+  user: "postgres"
+  dbname: "mydb"
+  sslmode: "sometimes"
+`)
+
+	_, err := LoadConfig(dir)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "server.port: must not exceed 65535")
+	assert.Contains(t, err.Error(), `server.trusted_proxies[1]: must be an IP address or CIDR, got "proxy.local"`)
+	assert.Contains(t, err.Error(), "logging.format: must be one of [text json]")
+	assert.Contains(t, err.Error(), "database.password: is required")
+	assert.Contains(t, err.Error(), "database.sslmode: must be one of")
+	assert.Contains(t, err.Error(), "auth.jwt_secret: is required")
+	assert.Contains(t, err.Error(), "auth.totp.encryption_key: is required")
+}
+
+func TestLoadConfig_RejectsDefaultSecret(t *testing.T) {
+	dir := writeConfig(t, `
+auth:
+  jwt_secret: "dev-only-secret-change-me-in-production"
+  totp:
+    encryption_key: "BiW8aTBG6wIoiYZ5psjtEVZ6+Gh/TTPsv+QEmncTvXI="
+`)
+
+	_, err := LoadConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.jwt_secret: must not be a published default value")
+	assert.Contains(t, err.Error(), "auth.totp.encryption_key: must not be a published default value")
+}
+
+func TestLoadConfig_UnknownKey(t *testing.T) {
+	dir := writeConfig(t, `
+server:
+  port: 8080
+  unknown_option: true
+logging:
+  level: "debug"
+  format: "xml"
+`)
+
+	_, err := LoadConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown_option")
+	assert.Contains(t, err.Error(), "logging.format: must be one of [text json]")
+	assert.Contains(t, err.Error(), "database.password: is required")
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	_, err := LoadConfig(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRestartRequired(t *testing.T) {
+	current := &Config{
+		Database:  PostgresConfig{Host: "localhost"},
+		RateLimit: RateLimitConfig{Backend: "memory", Rules: map[string]RateLimitRule{"users": {Requests: 10}}},
+	}
+
+	// Правила лимитов и логирование применяются на лету
+	loaded := *current
+	loaded.Logging.Level = "debug"
+	loaded.RateLimit.Rules = map[string]RateLimitRule{"users": {Requests: 20}}
+	assert.Empty(t, restartRequired(current, &loaded))
+
+	loaded.Admin.Token = "another-admin-token"
+	loaded.Database.Host = "db"
+	loaded.Auth.JWTSecret = "another-secret"
+	loaded.PasswordPolicy.MinLength = 12
+	loaded.PasswordHashing.Algorithm = "bcrypt"
+	loaded.Avatar.MaxBytes = 1
+	loaded.BlobStore.Backend = "local"
+	loaded.RateLimit.BucketTTL = time.Hour
+	assert.Equal(t, []string{"database", "admin", "rate limit", "avatar", "blob store", "auth", "password policy", "password hashing"},
+		restartRequired(current, &loaded))
+}
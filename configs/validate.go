@@ -0,0 +1,105 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+var validate *validator.Validate
+
+// Секреты, которые когда-либо публиковались в примерах конфигурации. С ними сервер не запускается
+var knownDefaultSecrets = map[string]bool{
+	"dev-only-secret-change-me-in-production":      true,
+	"BiW8aTBG6wIoiYZ5psjtEVZ6+Gh/TTPsv+QEmncTvXI=": true,
+}
+
+func init() {
+	validate = validator.New()
+
+	// В сообщениях об ошибках используем ключи из YAML, а не имена полей
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+
+	validate.RegisterValidation("not_default_secret", func(fl validator.FieldLevel) bool {
+		return !knownDefaultSecrets[fl.Field().String()]
+	})
+}
+
+// validateStruct возвращает список проблем в виде "ключ: описание"
+func validateStruct(cfg *Config) []string {
+	err := validate.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	problems := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		problems = append(problems, fmt.Sprintf("%s: %s", configKey(fe), describe(fe)))
+	}
+	return problems
+}
+
+// decodeProblems разбирает ошибку маппинга на отдельные проблемы: неизвестные ключи и неверные типы
+func decodeProblems(err error) []string {
+	var decodeErr *mapstructure.Error
+	if errors.As(err, &decodeErr) {
+		return decodeErr.Errors
+	}
+	return []string{err.Error()}
+}
+
+// configKey превращает "Config.database.password" в "database.password"
+func configKey(fe validator.FieldError) string {
+	_, key, _ := strings.Cut(fe.Namespace(), ".")
+	// Вложенные структуры со squash не добавляют уровень в YAML
+	return strings.ReplaceAll(key, ".LogSinkConfig", "")
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fe.Param(), fe.Value())
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s, got %v", fe.Param(), fe.Value())
+	case "max":
+		return fmt.Sprintf("must not exceed %s, got %v", fe.Param(), fe.Value())
+	case "not_default_secret":
+		return "must not be a published default value, generate a new secret"
+	case "cidr|ip":
+		return fmt.Sprintf("must be an IP address or CIDR, got %q", fe.Value())
+	case "url":
+		return "must be a valid URL"
+	case "base64":
+		return "must be base64-encoded"
+	case "datetime":
+		return fmt.Sprintf("must be a date in format %s, got %q", fe.Param(), fe.Value())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s, got %v", fe.Param(), fe.Value())
+	case "startswith":
+		return fmt.Sprintf("must start with %q, got %q", fe.Param(), fe.Value())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s, got %v", fe.Param(), fe.Value())
+	default:
+		return fmt.Sprintf("failed %q check", fe.Tag())
+	}
+}
@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Watcher следит за файлом конфигурации и применяет изменения, безопасные без перезапуска:
+// настройки логирования и лимиты запросов. Остальные изменения требуют перезапуска и только логируются.
+type Watcher struct {
+	path        string
+	reloadMu    sync.Mutex // перезагрузки по изменению файла и по сигналу выполняются по очереди
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(cfg *Config)
+}
+
+// NewWatcher создает наблюдателя за конфигурацией, загруженной из path
+func NewWatcher(path string, initial *Config) *Watcher {
+	return &Watcher{path: path, current: initial}
+}
+
+// Subscribe регистрирует обработчик, который вызывается после применения новой конфигурации
+func (w *Watcher) Subscribe(fn func(cfg *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Current возвращает текущую действующую конфигурацию
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// Watch запускает отслеживание изменений файла конфигурации
+func (w *Watcher) Watch() {
+	v := newViper(w.path)
+	if err := v.ReadInConfig(); err != nil {
+		logger.Errorf("Config watcher is disabled: %v", err)
+		return
+	}
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		logger.Infof("Config file changed: %s", e.Name)
+		w.Reload()
+	})
+	v.WatchConfig()
+}
+
+// Reload перечитывает конфигурацию и оповещает подписчиков.
+// Некорректная конфигурация отклоняется, продолжает действовать предыдущая.
+func (w *Watcher) Reload() {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	loaded, err := LoadConfig(w.path)
+	if err != nil {
+		logger.Errorf("Config reload rejected: %v", err)
+		return
+	}
+
+	w.mu.Lock()
+	next := *w.current
+	applyReloadable(&next, loaded)
+	warnRestartRequired(&next, loaded)
+	w.current = &next
+	subscribers := append([]func(cfg *Config){}, w.subscribers...)
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(&next)
+	}
+	logger.Info("Config reloaded")
+}
+
+// applyReloadable переносит в dst настройки, которые можно менять на лету
+func applyReloadable(dst, src *Config) {
+	dst.Logging = src.Logging
+
+	// Хранилище лимитов создается при старте, на лету меняются только правила
+	dst.RateLimit.Enabled = src.RateLimit.Enabled
+	dst.RateLimit.Rules = src.RateLimit.Rules
+}
+
+// warnRestartRequired логирует изменения, которые вступят в силу только после перезапуска
+func warnRestartRequired(current, loaded *Config) {
+	for _, section := range restartRequired(current, loaded) {
+		logger.Warnf("Config: %s settings changed, restart required to apply", section)
+	}
+}
+
+// restartRequired возвращает разделы, изменения которых не применяются на лету.
+// Значения не логируются: среди них есть секреты
+func restartRequired(current, loaded *Config) []string {
+	// Правила и включение лимитов применяются на лету, остальные настройки лимитов - при старте
+	currentLimits, loadedLimits := current.RateLimit, loaded.RateLimit
+	currentLimits.Enabled, currentLimits.Rules = false, nil
+	loadedLimits.Enabled, loadedLimits.Rules = false, nil
+
+	sections := []struct {
+		name            string
+		current, loaded any
+	}{
+		{"server", current.Server, loaded.Server},
+		{"gRPC", current.GRPC, loaded.GRPC},
+		{"API", current.API, loaded.API},
+		{"HTTP cache", current.HTTPCache, loaded.HTTPCache},
+		{"database", current.Database, loaded.Database},
+		{"admin", current.Admin, loaded.Admin},
+		{"rate limit", currentLimits, loadedLimits},
+		{"cache", current.Cache, loaded.Cache},
+		{"avatar", current.Avatar, loaded.Avatar},
+		{"blob store", current.BlobStore, loaded.BlobStore},
+		{"auth", current.Auth, loaded.Auth},
+		{"password policy", current.PasswordPolicy, loaded.PasswordPolicy},
+		{"password hashing", current.PasswordHashing, loaded.PasswordHashing},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.current, section.loaded) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Cluster распределяет запросы между основной базой и репликами для чтения.
+// Недоступные реплики исключаются по результатам проверок, без реплик чтение идет в основную базу
+type Cluster struct {
+	primary        *pgxpool.Pool
+	replicas       []*replica
+	readYourWrites bool
+	next           atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// routing - состояние маршрутизации одного запроса
+type routing struct {
+	mu      sync.Mutex
+	written bool
+	replica *replica
+}
+
+type routingKey struct{}
+
+type primaryKey struct{}
+
+// NewCluster создает кластер; реплики считаются доступными до первой проверки.
+// При readYourWrites чтения запроса после его записи идут в основную базу
+func NewCluster(primary *pgxpool.Pool, replicas []*pgxpool.Pool, readYourWrites bool) *Cluster {
+	c := &Cluster{primary: primary, readYourWrites: readYourWrites}
+	for _, pool := range replicas {
+		connConfig := pool.Config().ConnConfig
+		r := &replica{name: net.JoinHostPort(connConfig.Host, strconv.Itoa(int(connConfig.Port))), pool: pool}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	return c
+}
+
+// WithRouting подготавливает контекст запроса к маршрутизации: все чтения запроса идут в одну реплику,
+// чтобы данные не "откатывались" назад между запросами к разным репликам
+func WithRouting(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routingKey{}, &routing{})
+}
+
+// MarkWritten отмечает, что запрос изменил данные
+func MarkWritten(ctx context.Context) {
+	if state, ok := ctx.Value(routingKey{}).(*routing); ok {
+		state.mu.Lock()
+		state.written = true
+		state.mu.Unlock()
+	}
+}
+
+// WithPrimary направляет чтения с контекстом ctx в основную базу,
+// например, когда прочитанные данные кэшируются и не должны отставать
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Primary возвращает основную базу для записи и чтений, требующих актуальных данных
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Reader возвращает пул для чтения: закрепленную за запросом или следующую доступную реплику
+func (c *Cluster) Reader(ctx context.Context) *pgxpool.Pool {
+	if len(c.replicas) == 0 || ctx.Value(primaryKey{}) != nil {
+		return c.primary
+	}
+
+	state, ok := ctx.Value(routingKey{}).(*routing)
+	if !ok {
+		if r := c.pickReplica(); r != nil {
+			return r.pool
+		}
+		return c.primary
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.written && c.readYourWrites {
+		return c.primary
+	}
+	if state.replica == nil || !state.replica.healthy.Load() {
+		state.replica = c.pickReplica()
+	}
+	if state.replica == nil {
+		return c.primary
+	}
+	return state.replica.pool
+}
+
+// pickReplica выбирает доступные реплики по кругу, nil - доступных нет
+func (c *Cluster) pickReplica() *replica {
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// RunHealthChecks проверяет реплики каждые period до отмены ctx
+func (c *Cluster) RunHealthChecks(ctx context.Context, period, timeout time.Duration) error {
+	if len(c.replicas) == 0 || period <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		c.checkReplicas(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context, timeout time.Duration) {
+	for _, r := range c.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.pool.Ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.Infof("Read replica %s is available again", r.name)
+			} else {
+				logger.Warnf("Read replica %s is unavailable, reads fall back to other replicas or primary: %v", r.name, err)
+			}
+		}
+	}
+}
+
+// Close закрывает пулы реплик, основной пул закрывается отдельно
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.pool.Close()
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// newLazyPool создает пул без подключения к серверу
+func newLazyPool(t *testing.T, host string) *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), "postgres://user:pass@"+host+":5432/db")
+	assert.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestCluster_Reader(t *testing.T) {
+	primary := newLazyPool(t, "primary")
+	replica1 := newLazyPool(t, "replica1")
+	replica2 := newLazyPool(t, "replica2")
+	cluster := NewCluster(primary, []*pgxpool.Pool{replica1, replica2}, true)
+
+	// Чтения одного запроса идут в одну реплику
+	ctx := WithRouting(context.Background())
+	reader := cluster.Reader(ctx)
+	assert.NotEqual(t, primary, reader)
+	assert.Equal(t, reader, cluster.Reader(ctx))
+
+	// Недоступная реплика заменяется другой
+	for _, r := range cluster.replicas {
+		if r.pool == reader {
+			r.healthy.Store(false)
+		}
+	}
+	other := cluster.Reader(ctx)
+	assert.NotEqual(t, reader, other)
+	assert.NotEqual(t, primary, other)
+
+	// После записи запрос читает из основной базы
+	MarkWritten(ctx)
+	assert.Equal(t, primary, cluster.Reader(ctx))
+
+	// Явное чтение из основной базы
+	assert.Equal(t, primary, cluster.Reader(WithPrimary(context.Background())))
+
+	// Без доступных реплик чтение идет в основную базу
+	for _, r := range cluster.replicas {
+		r.healthy.Store(false)
+	}
+	assert.Equal(t, primary, cluster.Reader(context.Background()))
+}
@@ -2,9 +2,15 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	logger "github.com/sirupsen/logrus"
 
@@ -13,40 +19,32 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 
 	"simple_crud_go/configs"
+	"simple_crud_go/internal/tenant"
 )
 
-func ConnectPostgres(cfg *configs.PostgresConfig) (*pgxpool.Pool, error) {
-	// Формируем строку подключения
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, cfg.SSLMode,
-	)
-
-	// Настраиваем контекст для подключения
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Параметры повторных попыток подключения при старте
+const (
+	initialRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
+)
 
-	// Создаем пул соединений
-	poolConfig, err := pgxpool.ParseConfig(dsn)
+func ConnectPostgres(cfg *configs.PostgresConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := newPoolConfig(cfg, cfg.Host, cfg.Port)
 	if err != nil {
 		logger.Errorf("Error parsing PostgreSQL DSN: %v", err)
 		return nil, err
 	}
 
-	// Применяем дополнительные настройки пула
-	poolConfig.MaxConns = 50                       // Максимальное количество соединений
-	poolConfig.MinConns = 5                        // Минимальное количество соединений
-	poolConfig.HealthCheckPeriod = 1 * time.Minute // Период проверки соединений
-
-	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		logger.Errorf("Failed to connect to PostgreSQL: %v", err)
 		return nil, err
 	}
 
-	// Проверяем подключение
-	if err := pool.Ping(ctx); err != nil {
+	// Проверяем подключение, пока база не станет доступна или не истечет время ожидания
+	if err := pingWithRetry(pool, cfg); err != nil {
 		logger.Errorf("PostgreSQL ping failed: %v", err)
+		pool.Close()
 		return nil, err
 	}
 
@@ -54,18 +52,184 @@ func ConnectPostgres(cfg *configs.PostgresConfig) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-func ApplyMigrations() {
-	m, err := migrate.New(
-		"file:///app/internal/db/migrations",
-		"postgres://postgres:postgres@db:5432/postgres?sslmode=disable",
-	)
+// ConnectReplicas создает пулы реплик для чтения с настройками основной базы.
+// Недоступность реплики при старте не ошибка: ее состояние отслеживают проверки Cluster
+func ConnectReplicas(cfg *configs.PostgresConfig) ([]*pgxpool.Pool, error) {
+	pools := make([]*pgxpool.Pool, 0, len(cfg.Replicas))
+	for _, r := range cfg.Replicas {
+		poolConfig, err := newPoolConfig(cfg, r.Host, r.Port)
+		if err != nil {
+			closePools(pools)
+			return nil, err
+		}
+
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			closePools(pools)
+			return nil, fmt.Errorf("replica %s:%d: %w", r.Host, r.Port, err)
+		}
+		pools = append(pools, pool)
+		logger.Infof("Using PostgreSQL read replica at %s:%d", r.Host, r.Port)
+	}
+	return pools, nil
+}
+
+func closePools(pools []*pgxpool.Pool) {
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
+
+// newPoolConfig формирует настройки пула для сервера host:port
+func newPoolConfig(cfg *configs.PostgresConfig, host string, port int) (*pgxpool.Config, error) {
+	// Создаем пул соединений
+	poolConfig, err := pgxpool.ParseConfig(postgresDSN(cfg, host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	// Применяем дополнительные настройки пула
+	poolConfig.MaxConns = cfg.MaxConns                   // Максимальное количество соединений
+	poolConfig.MinConns = cfg.MinConns                   // Минимальное количество соединений
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod // Период проверки соединений
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+
+	// Параметры сессии PostgreSQL
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.ApplicationName != "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
+	}
+
+	// Логируем запросы вместе с идентификатором запроса
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+
+	// Перед выдачей соединения выставляем арендатора запроса для политик RLS
+	if cfg.RowLevelSecurity {
+		poolConfig.BeforeAcquire = setTenant
+	}
+
+	return poolConfig, nil
+}
+
+// setTenant записывает арендатора из контекста в app.tenant_id, при ошибке соединение не выдается
+func setTenant(ctx context.Context, conn *pgx.Conn) bool {
+	tenantID := strconv.Itoa(tenant.FromContext(ctx))
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", tenantID); err != nil {
+		logger.WithError(err).Error("Failed to set tenant for connection")
+		return false
+	}
+	return true
+}
+
+// ErrRowLevelSecurityBypassed - политики RLS не действуют для роли приложения
+var ErrRowLevelSecurityBypassed = errors.New("row level security is bypassed by the database role")
+
+// CheckRowLevelSecurity проверяет, что политики RLS таблицы users действуют для роли пула.
+// Владелец таблицы, суперпользователь и роль с BYPASSRLS их не соблюдают, и арендаторы не изолированы
+func CheckRowLevelSecurity(ctx context.Context, pool *pgxpool.Pool) error {
+	var bypassed bool
+	err := pool.QueryRow(ctx, `
+		SELECT pg_has_role(current_user, c.relowner, 'USAGE') OR r.rolsuper OR r.rolbypassrls
+		FROM pg_class c, pg_roles r
+		WHERE c.oid = 'users'::regclass AND r.rolname = current_user`).Scan(&bypassed)
 	if err != nil {
-		logger.Fatalf("Could not initialize migrate: %v", err)
+		return fmt.Errorf("check row level security: %w", err)
 	}
+	if bypassed {
+		return ErrRowLevelSecurityBypassed
+	}
+	return nil
+}
+
+// pingWithRetry проверяет подключение с экспоненциальной задержкой между попытками
+func pingWithRetry(pool *pgxpool.Pool, cfg *configs.PostgresConfig) error {
+	return withRetry(cfg, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+		defer cancel()
+		return pool.Ping(ctx)
+	})
+}
+
+// withRetry повторяет fn с экспоненциальной задержкой, пока не истечет connect_retry_timeout
+func withRetry(cfg *configs.PostgresConfig, fn func() error) error {
+	deadline := time.Now().Add(cfg.ConnectRetryTimeout)
+	backoff := initialRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warnf("PostgreSQL is not available (attempt %d), retrying in %s: %v", attempt, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// Каталог миграций в образе приложения
+const migrationsSource = "file:///app/internal/db/migrations"
+
+// ApplyMigrations применяет миграции к основной базе из конфигурации.
+// Подключение повторяется так же, как в ConnectPostgres, ошибки самих миграций не повторяются.
+func ApplyMigrations(cfg *configs.PostgresConfig) error {
+	return applyMigrations(cfg, migrationsSource)
+}
+
+func applyMigrations(cfg *configs.PostgresConfig, source string) error {
+	var m *migrate.Migrate
+	err := withRetry(cfg, func() error {
+		var err error
+		m, err = migrate.New(source, migrationDSN(cfg))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not initialize migrate: %w", err)
+	}
+	defer m.Close()
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		logger.Fatalf("Could not apply migrations: %v", err)
+		return fmt.Errorf("could not apply migrations: %w", err)
 	}
 
 	logger.Println("Migrations applied successfully!")
+	return nil
+}
+
+// postgresDSN формирует строку подключения к серверу host:port, учетные данные экранируются
+func postgresDSN(cfg *configs.PostgresConfig, host string, port int) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:     "/" + cfg.DBName,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// migrationDSN - строка подключения для migrate. Драйвер принимает таймаут подключения только в секундах
+func migrationDSN(cfg *configs.PostgresConfig) string {
+	dsn := postgresDSN(cfg, cfg.Host, cfg.Port)
+	if cfg.ConnectTimeout > 0 {
+		seconds := int64(math.Ceil(cfg.ConnectTimeout.Seconds()))
+		dsn += "&connect_timeout=" + strconv.FormatInt(seconds, 10)
+	}
+	return dsn
 }
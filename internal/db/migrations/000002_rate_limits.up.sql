@@ -0,0 +1,9 @@
+CREATE UNLOGGED TABLE rate_limit_buckets
+(
+    key        varchar(255) primary key,
+    tokens     double precision not null,
+    allowed    boolean not null,
+    updated_at timestamp not null default now()
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
@@ -0,0 +1,10 @@
+CREATE TABLE login_failures
+(
+    scope           varchar(16)  not null,
+    key             varchar(255) not null,
+    failures        int          not null default 0,
+    lockouts        int          not null default 0,
+    locked_until    timestamp,
+    last_failure_at timestamp    not null default now(),
+    primary key (scope, key)
+);
@@ -0,0 +1,7 @@
+DROP TABLE recovery_codes;
+
+ALTER TABLE users
+    DROP COLUMN role,
+    DROP COLUMN totp_secret,
+    DROP COLUMN totp_enabled,
+    DROP COLUMN totp_last_step;
\ No newline at end of file
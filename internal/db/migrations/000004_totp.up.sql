@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN role           varchar(16) not null default 'user',
+    ADD COLUMN totp_secret    text,
+    ADD COLUMN totp_enabled   boolean     not null default false,
+    ADD COLUMN totp_last_step bigint;
+
+CREATE TABLE recovery_codes
+(
+    id        serial primary key,
+    user_id   int         not null references users (id) on delete cascade,
+    code_hash varchar(64) not null,
+    used_at   timestamp
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys
+(
+    id           serial primary key,
+    name         varchar(100) not null,
+    prefix       varchar(16)  not null unique,
+    secret_hash  varchar(64)  not null,
+    scopes       text[]       not null,
+    expires_at   timestamp,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    created_at   timestamp    not null default now()
+);
@@ -0,0 +1,22 @@
+CREATE TABLE user_identities
+(
+    id         serial primary key,
+    user_id    int          not null references users (id) on delete cascade,
+    provider   varchar(64)  not null,
+    subject    varchar(255) not null,
+    email      varchar(255),
+    created_at timestamp    not null default now(),
+    unique (provider, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TABLE oidc_states
+(
+    state         varchar(64)  primary key,
+    provider      varchar(64)  not null,
+    nonce         varchar(64)  not null,
+    code_verifier varchar(128) not null,
+    link_user_id  int references users (id) on delete cascade,
+    expires_at    timestamp    not null
+);
@@ -0,0 +1,13 @@
+CREATE TABLE sessions
+(
+    id           varchar(64)  primary key,
+    user_id      int          not null references users (id) on delete cascade,
+    ip           varchar(64)  not null,
+    user_agent   varchar(512) not null,
+    created_at   timestamp    not null default now(),
+    last_seen_at timestamp    not null default now(),
+    expires_at   timestamp    not null,
+    revoked_at   timestamp
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN updated_at timestamp not null default now();
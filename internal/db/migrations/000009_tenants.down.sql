@@ -0,0 +1,16 @@
+DROP POLICY users_tenant_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE user_identities DROP CONSTRAINT user_identities_provider_subject_key;
+ALTER TABLE user_identities ADD CONSTRAINT user_identities_provider_subject_key UNIQUE (provider, subject);
+
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE user_identities DROP COLUMN tenant_id;
+ALTER TABLE oidc_states DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+
+DROP TABLE tenants;
\ No newline at end of file
@@ -0,0 +1,35 @@
+CREATE TABLE tenants
+(
+    id         serial       primary key,
+    name       varchar(255) not null unique,
+    created_at timestamp    not null default now()
+);
+
+-- Существующие пользователи переходят к арендатору по умолчанию
+INSERT INTO tenants (id, name) VALUES (1, 'default');
+SELECT setval('tenants_id_seq', (SELECT max(id) FROM tenants));
+
+ALTER TABLE users ADD COLUMN tenant_id int not null default 1 references tenants (id);
+
+-- Вход через OIDC завершается у того же арендатора, у которого начат
+ALTER TABLE oidc_states ADD COLUMN tenant_id int not null default 1 references tenants (id);
+
+-- Внешний аккаунт привязывается к пользователю в каждом арендаторе отдельно
+ALTER TABLE user_identities ADD COLUMN tenant_id int not null default 1 references tenants (id);
+
+-- API-ключ действует в пределах одного арендатора
+ALTER TABLE api_keys ADD COLUMN tenant_id int not null default 1 references tenants (id);
+
+-- Уникальность в пределах арендатора, имена ограничений сохраняются
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (tenant_id, username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (tenant_id, email);
+ALTER TABLE user_identities DROP CONSTRAINT user_identities_provider_subject_key;
+ALTER TABLE user_identities ADD CONSTRAINT user_identities_provider_subject_key UNIQUE (tenant_id, provider, subject);
+
+-- Row-level security: действует для ролей, не владеющих таблицей, арендатор берется из app.tenant_id
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
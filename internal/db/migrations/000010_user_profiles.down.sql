@@ -0,0 +1,9 @@
+ALTER TABLE users
+    DROP COLUMN display_name,
+    DROP COLUMN first_name,
+    DROP COLUMN last_name,
+    DROP COLUMN phone,
+    DROP COLUMN locale,
+    DROP COLUMN timezone,
+    DROP COLUMN metadata,
+    DROP COLUMN avatar;
\ No newline at end of file
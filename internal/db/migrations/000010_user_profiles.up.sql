@@ -0,0 +1,11 @@
+-- Поля профиля необязательны, пустая строка - значение не указано
+ALTER TABLE users
+    ADD COLUMN display_name varchar(100) not null default '',
+    ADD COLUMN first_name   varchar(100) not null default '',
+    ADD COLUMN last_name    varchar(100) not null default '',
+    ADD COLUMN phone        varchar(16)  not null default '',
+    ADD COLUMN locale       varchar(35)  not null default '',
+    ADD COLUMN timezone     varchar(64)  not null default '',
+    ADD COLUMN metadata     jsonb        not null default '{}',
+    -- Ключи файлов аватара в хранилище: исходное изображение и миниатюры
+    ADD COLUMN avatar       jsonb;
@@ -0,0 +1,4 @@
+DROP INDEX users_username_key;
+DROP INDEX users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (tenant_id, username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (tenant_id, email);
\ No newline at end of file
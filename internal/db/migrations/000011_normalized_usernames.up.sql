@@ -0,0 +1,42 @@
+-- Имена пользователей и email уникальны без учета регистра и хранятся в форме NFKC без пробелов по краям
+-- (новые значения нормализует сервис, набор пробелов совпадает с trimmedSpace в internal/service/normalize.go).
+-- Если существующие записи совпадают после нормализации, миграция останавливается со списком таких
+-- пользователей: их нужно переименовать или объединить вручную
+DO $$
+DECLARE
+    collisions text;
+BEGIN
+    SELECT string_agg(format('tenant %s, %s %L: users %s', tenant_id, field, value, ids), E'\n')
+    INTO collisions
+    FROM (
+        SELECT tenant_id, 'username' AS field, lower(btrim(normalize(username, NFKC), E' \t\n\x0B\f\r\u0085\u1680\u2028\u2029')) AS value,
+               string_agg(id::text, ', ' ORDER BY id) AS ids
+        FROM users
+        GROUP BY 1, 2, 3
+        HAVING count(*) > 1
+        UNION ALL
+        SELECT tenant_id, 'email', lower(btrim(normalize(email, NFKC), E' \t\n\x0B\f\r\u0085\u1680\u2028\u2029')), string_agg(id::text, ', ' ORDER BY id)
+        FROM users
+        WHERE email IS NOT NULL
+        GROUP BY 1, 2, 3
+        HAVING count(*) > 1
+    ) duplicates;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION E'users collide after username/email normalization, resolve them and rerun the migration:\n%', collisions;
+    END IF;
+END
+$$;
+
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+
+-- Нормализуем существующие записи так же, как сервис: у email в нижний регистр переводится только домен
+UPDATE users SET username = btrim(normalize(username, NFKC), E' \t\n\x0B\f\r\u0085\u1680\u2028\u2029');
+UPDATE users SET email = btrim(normalize(email, NFKC), E' \t\n\x0B\f\r\u0085\u1680\u2028\u2029') WHERE email IS NOT NULL;
+UPDATE users SET email = substring(email from '^(.*)@') || '@' || lower(substring(email from '@([^@]*)$'))
+WHERE email LIKE '%@%';
+
+-- Имена индексов совпадают с прежними ограничениями, по ним распознаются занятые имя и email
+CREATE UNIQUE INDEX users_username_key ON users (tenant_id, lower(username));
+CREATE UNIQUE INDEX users_email_key ON users (tenant_id, lower(email));
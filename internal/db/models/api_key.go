@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Области действия API-ключей
+const (
+	APIKeyScopeUsersRead  = "users:read"
+	APIKeyScopeUsersWrite = "users:write"
+)
+
+// API-ключ для доступа сервисов без интерактивного входа. Хранится только хэш секрета
+type APIKey struct {
+	ID         int        `json:"id"`
+	TenantID   int        `json:"tenant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SecretHash string     `json:"-"`
+}
+
+// Данные для создания API-ключа, без expires_at ключ бессрочный, без tenant_id - ключ арендатора по умолчанию
+type APIKeyInput struct {
+	TenantID  int        `json:"tenant_id" validate:"omitempty,min=1"`
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (a *APIKeyInput) Validate() error {
+	return validate.Struct(a)
+}
+
+// Созданный или перевыпущенный ключ, полный ключ показывается только в этом ответе
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "errors"
+
+// ErrUnauthenticated - учетные данные переданы, но недействительны: неизвестны, просрочены или отозваны.
+// Другие ошибки проверки учетных данных означают сбой проверки, а не отказ в доступе
+var ErrUnauthenticated = errors.New("invalid credentials")
+
+// Данные для входа по логину и паролю.
+// Пользователям с подключенным TOTP нужен код из приложения или код восстановления
+type LoginInput struct {
+	Username     string `json:"username" validate:"required"`
+	Password     string `json:"password" validate:"required"`
+	TOTPCode     string `json:"totp_code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+func (l *LoginInput) Validate() error {
+	return validate.Struct(l)
+}
+
+// Области действия токена доступа
+const (
+	// ScopeFull - полный доступ
+	ScopeFull = ""
+	// ScopeTOTPEnroll - токен администратора без второго фактора, разрешает только подключение TOTP
+	ScopeTOTPEnroll = "totp_enroll"
+)
+
+// Ответ с токеном доступа
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// Данные из проверенного токена доступа
+type TokenClaims struct {
+	UserID    int
+	TenantID  int
+	SessionID string
+	Scope     string
+}
+
+// Состояние TOTP пользователя. Secret хранится зашифрованным
+type TOTPState struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// Ответ на подключение TOTP: секрет для ручного ввода, otpauth URI и QR-код в PNG (base64)
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code_png"`
+}
+
+// Подтверждение подключения TOTP первым кодом из приложения
+type TOTPConfirmInput struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+func (t *TOTPConfirmInput) Validate() error {
+	return validate.Struct(t)
+}
+
+// Одноразовые коды восстановления, показываются один раз
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
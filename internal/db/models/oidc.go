@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Состояние незавершенного входа через OIDC, хранится до возврата пользователя от провайдера
+type OIDCState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	LinkUserID   *int // Пользователь, к которому привязывается внешний аккаунт; nil - вход
+	TenantID     int  // Арендатор, у которого начат вход
+	ExpiresAt    time.Time
+}
+
+// Внешний аккаунт пользователя у провайдера OIDC
+type UserIdentity struct {
+	UserID   int
+	Provider string
+	Subject  string
+	Email    string
+}
+
+// Ссылка на страницу входа провайдера
+type OIDCAuthURLResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// Результат возврата от провайдера: токен при входе или ID пользователя при привязке
+type OIDCResult struct {
+	Token        *TokenResponse `json:"token,omitempty"`
+	Created      bool           `json:"created"`
+	LinkedUserID int            `json:"linked_user_id,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Сессия пользователя: каждый выданный токен доступа принадлежит сессии,
+// отозванная сессия делает токен недействительным
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"-"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// Данные клиента, с которого выполняется вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Результат выхода на всех устройствах
+type RevokedSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
@@ -1,14 +1,61 @@
 package models
 
-import "github.com/go-playground/validator/v10"
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username" validate:"required,min=3,max=20"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required"`
+	UserProfile
+
+	// Служебные поля, не принимаются и не отдаются через API
+	TenantID    int            `json:"-"`
+	Role        string         `json:"-"`
+	TOTPEnabled bool           `json:"-"`
+	UpdatedAt   time.Time      `json:"-"`
+	Avatar      *AvatarObjects `json:"-"`
+}
+
+// UserProfile - необязательные поля профиля, пустая строка - значение не указано
+type UserProfile struct {
+	DisplayName string `json:"display_name,omitempty" validate:"omitempty,max=100"`
+	FirstName   string `json:"first_name,omitempty" validate:"omitempty,max=100"`
+	LastName    string `json:"last_name,omitempty" validate:"omitempty,max=100"`
+	// Телефон в формате E.164
+	Phone string `json:"phone,omitempty" validate:"omitempty,e164" example:"+14155552671"`
+	// Языковой тег BCP 47
+	Locale string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag,max=35" example:"en-US"`
+	// Часовой пояс из базы IANA
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,timezone,max=64" example:"Europe/Berlin"`
+	// Произвольные данные клиента
+	Metadata map[string]any `json:"metadata,omitempty" validate:"omitempty,max_json_bytes=8192"`
+}
+
+// AvatarObjects - ключи файлов аватара в хранилище, миниатюры по стороне в пикселях
+type AvatarObjects struct {
+	Original   string         `json:"original"`
+	Thumbnails map[int]string `json:"thumbnails"`
+}
+
+// Avatar - адреса аватара для клиентов
+type Avatar struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
 }
 
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // Метод для валидации данных
 func (u *User) Validate() error {
 	return validate.Struct(u)
@@ -23,20 +70,100 @@ type UserResponse struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	UserProfile
+	Avatar *Avatar `json:"avatar,omitempty"`
+
+	// Ключи файлов аватара, по ним заполняется Avatar
+	AvatarObjects *AvatarObjects `json:"-"`
+}
+
+// UserLookupInput - идентификаторы для пакетного поиска пользователей
+type UserLookupInput struct {
+	Usernames []string `json:"usernames" validate:"dive,required,max=255"`
+	Emails    []string `json:"emails" validate:"dive,required,max=255"`
+}
+
+func (u *UserLookupInput) Validate() error {
+	return validate.Struct(u)
+}
+
+// UserLookupResult - пользователи по запрошенным идентификаторам. Ключи - идентификаторы в том виде,
+// в каком их передал клиент, по идентификатору, которому никто не соответствует, - null
+type UserLookupResult struct {
+	Usernames map[string]*UserResponse `json:"usernames"`
+	Emails    map[string]*UserResponse `json:"emails"`
+}
+
+// UserListVersion меняется при любом изменении списка пользователей, используется для условных запросов
+type UserListVersion struct {
+	Count        int
+	LastModified time.Time
+}
+
+// UserFilter отбирает пользователей по вхождению подстроки без учета регистра, пустые поля не учитываются
+type UserFilter struct {
+	Username string
+	Email    string
 }
 
 type UserUpdate struct {
 	ID       int    `json:"id"`
 	Username string `json:"username" validate:"omitempty,min=3,max=20"`
 	Email    string `json:"email" validate:"omitempty,email"`
+
+	// Поля профиля: отсутствует - не меняется, пустая строка - очищается.
+	// Проверяются по правилам UserProfile
+	DisplayName *string `json:"display_name"`
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	Phone       *string `json:"phone" example:"+14155552671"`
+	Locale      *string `json:"locale" example:"en-US"`
+	Timezone    *string `json:"timezone" example:"Europe/Berlin"`
+	// Заменяет данные клиента целиком, отсутствует - не меняется
+	Metadata map[string]any `json:"metadata"`
 }
 
 func (u *UserUpdate) Validate() error {
-	return validate.Struct(u)
+	if err := validate.Struct(u); err != nil {
+		return err
+	}
+	return validate.Struct(u.profile())
+}
+
+// profile собирает переданные поля профиля, отсутствующие остаются пустыми и проходят проверку
+func (u *UserUpdate) profile() UserProfile {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return UserProfile{
+		DisplayName: value(u.DisplayName),
+		FirstName:   value(u.FirstName),
+		LastName:    value(u.LastName),
+		Phone:       value(u.Phone),
+		Locale:      value(u.Locale),
+		Timezone:    value(u.Timezone),
+		Metadata:    u.Metadata,
+	}
 }
 
 var validate *validator.Validate
 
+// TagMaxJSONBytes ограничивает размер значения в JSON, параметр - число байт
+const TagMaxJSONBytes = "max_json_bytes"
+
 func init() {
 	validate = validator.New()
+	_ = validate.RegisterValidation(TagMaxJSONBytes, maxJSONBytes)
+}
+
+func maxJSONBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+	data, err := json.Marshal(fl.Field().Interface())
+	return err == nil && len(data) <= limit
 }
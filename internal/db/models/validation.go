@@ -0,0 +1,36 @@
+package models
+
+import "strings"
+
+// Теги нарушений, которые проверяются в сервисном слое, а не тегами validate
+const (
+	// TagFuture - дата должна быть в будущем
+	TagFuture = "future"
+
+	// Нарушения политики паролей. Длина пароля ограничена в байтах (ограничение bcrypt)
+	TagPasswordMaxBytes = "password_max_bytes"
+	TagPasswordUpper    = "password_upper"
+	TagPasswordLower    = "password_lower"
+	TagPasswordDigit    = "password_digit"
+	TagPasswordSpecial  = "password_special"
+	TagPasswordUserInfo = "password_user_info"
+	TagPasswordBreached = "password_breached"
+)
+
+// FieldError - нарушение правила проверки для поля
+type FieldError struct {
+	Field string
+	Tag   string
+	Param string
+}
+
+// ValidationErrors - нарушения правил, найденные сервисом
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, fe := range v {
+		messages = append(messages, fe.Field+": "+fe.Tag)
+	}
+	return "validation failed: " + strings.Join(messages, ", ")
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/pkg/logging"
+)
+
+// newQueryTracer возвращает трейсер pgx, который пишет запросы к базе в логгер запроса,
+// чтобы логи репозитория содержали тот же request_id, что и логи обработчиков.
+func newQueryTracer() *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   tracelog.LoggerFunc(logQuery),
+		LogLevel: tracelog.LogLevelTrace,
+	}
+}
+
+func logQuery(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]interface{}) {
+	// Аргументы запросов могут содержать хэши паролей, поэтому в лог не попадают
+	delete(data, "args")
+	entry := logging.FromContext(ctx).WithFields(logrus.Fields(data))
+
+	switch level {
+	case tracelog.LogLevelError:
+		entry.Error(msg)
+	case tracelog.LogLevelWarn:
+		entry.Warn(msg)
+	case tracelog.LogLevelInfo, tracelog.LogLevelDebug:
+		// pgx логирует каждый успешный запрос на уровне info, для нас это отладочная информация
+		entry.Debug(msg)
+	default:
+		entry.Trace(msg)
+	}
+}
@@ -0,0 +1,42 @@
+package gql
+
+import (
+	"context"
+	"slices"
+)
+
+type (
+	apiKeyScopesKey struct{}
+	userIDKey       struct{}
+)
+
+// WithAPIKeyScopes отмечает запрос как выполняемый по API-ключу с областями scopes
+func WithAPIKeyScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, apiKeyScopesKey{}, scopes)
+}
+
+// requireAPIKeyScope требует область scope у запросов с API-ключом, как middleware.RequireAPIKeyScope
+func requireAPIKeyScope(ctx context.Context, scope string) error {
+	scopes, ok := ctx.Value(apiKeyScopesKey{}).([]string)
+	if ok && !slices.Contains(scopes, scope) {
+		return newError(codeForbidden, "API key does not have the required scope")
+	}
+	return nil
+}
+
+// WithUserID отмечает запрос как выполняемый пользователем userID по токену доступа
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// requireSelfOrAPIKey пропускает пользователя только к его собственной записи, а запрос
+// с API-ключом - к любой записи, как middleware.RequireSelfOrAPIKey
+func requireSelfOrAPIKey(ctx context.Context, id int) error {
+	if _, ok := ctx.Value(apiKeyScopesKey{}).([]string); ok {
+		return nil
+	}
+	if userID, ok := ctx.Value(userIDKey{}).(int); !ok || userID != id {
+		return newError(codeForbidden, "You can only change your own account")
+	}
+	return nil
+}
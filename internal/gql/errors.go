@@ -0,0 +1,67 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
+	"simple_crud_go/pkg/logging"
+)
+
+// Коды ошибок в extensions.code
+const (
+	codeBadUserInput  = "BAD_USER_INPUT"
+	codeNotFound      = "NOT_FOUND"
+	codeAlreadyExists = "ALREADY_EXISTS"
+	codeForbidden     = "FORBIDDEN"
+	codeInternal      = "INTERNAL"
+)
+
+// resolverError - ошибка для клиента с машиночитаемым кодом
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func newError(code, message string) error {
+	return &resolverError{message: message, code: code}
+}
+
+// toError переводит ошибку сервиса в ошибку GraphQL, как это делают HTTP-обработчики
+func toError(ctx context.Context, err error) error {
+	var validationErrors models.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		return newError(codeBadUserInput, error_handler.ParseValidationErrors(err))
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, service.ErrUserNotFound):
+		return newError(codeNotFound, "User not found")
+	}
+
+	code, constraint := error_handler.ErrorCode(err)
+	if code == "23503" && constraint == "users_tenant_id_fkey" {
+		return newError(codeBadUserInput, "Unknown tenant")
+	}
+	if code == "23505" {
+		switch constraint {
+		case "users_username_key":
+			return newError(codeAlreadyExists, "Username is already exist")
+		case "users_email_key":
+			return newError(codeAlreadyExists, "Email is already exist")
+		}
+	}
+
+	logging.FromContext(ctx).Errorf("GraphQL resolver failed: %v", err)
+	return newError(codeInternal, "Something went wrong")
+}
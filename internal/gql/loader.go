@@ -0,0 +1,58 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/service"
+)
+
+// Сколько ждать остальные запросы ID перед выборкой одним запросом
+const loaderWait = 2 * time.Millisecond
+
+type userLoader = dataloader.Loader[int, models.UserResponse]
+
+type userLoaderKey struct{}
+
+// newUserLoader собирает обращения к пользователям по ID в один вызов GetUsersByIDs
+func newUserLoader(users service.UserService) *userLoader {
+	batch := func(ctx context.Context, ids []int) []*dataloader.Result[models.UserResponse] {
+		results := make([]*dataloader.Result[models.UserResponse], len(ids))
+
+		found, err := users.GetUsersByIDs(ctx, ids)
+		if err != nil {
+			for i := range results {
+				results[i] = &dataloader.Result[models.UserResponse]{Error: err}
+			}
+			return results
+		}
+
+		byID := make(map[int]models.UserResponse, len(found))
+		for _, user := range found {
+			byID[user.ID] = user
+		}
+
+		// Результаты должны идти в порядке ключей
+		for i, id := range ids {
+			if user, ok := byID[id]; ok {
+				results[i] = &dataloader.Result[models.UserResponse]{Data: user}
+			} else {
+				results[i] = &dataloader.Result[models.UserResponse]{Error: service.ErrUserNotFound}
+			}
+		}
+		return results
+	}
+
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait[int, models.UserResponse](loaderWait))
+}
+
+func withUserLoader(ctx context.Context, loader *userLoader) context.Context {
+	return context.WithValue(ctx, userLoaderKey{}, loader)
+}
+
+func userLoaderFrom(ctx context.Context) *userLoader {
+	return ctx.Value(userLoaderKey{}).(*userLoader)
+}
@@ -0,0 +1,31 @@
+package gql
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+)
+
+// Сборка GraphQL Playground из github.com/wundergraph/graphql-go-tools v1.67.4 (pkg/playground/files, лицензия MIT).
+// Файлы встроены в бинарник, страница не загружает скриптов со сторонних серверов
+//
+//go:embed playground/playground.html playground/*.css playground/*.js playground/*.png
+var playgroundFiles embed.FS
+
+var playgroundPage = template.Must(template.ParseFS(playgroundFiles, "playground/playground.html"))
+
+// Playground возвращает страницу GraphQL Playground для отправки запросов на endpoint.
+// Скрипты и стили страница загружает по пути assets, их отдает PlaygroundAssets
+func Playground(endpoint, assets string) ([]byte, error) {
+	var page bytes.Buffer
+	err := playgroundPage.Execute(&page, struct{ Endpoint, Assets string }{Endpoint: endpoint, Assets: assets})
+	return page.Bytes(), err
+}
+
+// PlaygroundAssets возвращает скрипты, стили и изображения страницы Playground
+func PlaygroundAssets() http.FileSystem {
+	assets, _ := fs.Sub(playgroundFiles, "playground")
+	return http.FS(assets)
+}
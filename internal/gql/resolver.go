@@ -0,0 +1,284 @@
+package gql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
+)
+
+// Размер страницы users
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type resolver struct {
+	users service.UserService
+}
+
+type userFilterInput struct {
+	Username *string
+	Email    *string
+}
+
+type createUserInput struct {
+	Username string
+	Email    string
+	Password string
+}
+
+type updateUserInput struct {
+	Username *string
+	Email    *string
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if err := requireAPIKeyScope(ctx, models.APIKeyScopeUsersRead); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := userLoaderFrom(ctx).Load(ctx, id)()
+	if errors.Is(err, service.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Filter *userFilterInput
+	First  *int32
+	After  *string
+}) (*userConnectionResolver, error) {
+	if err := requireAPIKeyScope(ctx, models.APIKeyScopeUsersRead); err != nil {
+		return nil, err
+	}
+
+	first := defaultPageSize
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first < 0 {
+		return nil, newError(codeBadUserInput, "first must not be negative")
+	}
+	first = min(first, maxPageSize)
+
+	var afterID int
+	if args.After != nil {
+		id, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, newError(codeBadUserInput, "after is not a valid cursor")
+		}
+		afterID = id
+	}
+
+	var filter models.UserFilter
+	if args.Filter != nil {
+		filter.Username = deref(args.Filter.Username)
+		filter.Email = deref(args.Filter.Email)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	users, err := r.users.ListUserPage(ctx, filter, afterID, first+1)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+
+	conn := &userConnectionResolver{}
+	if len(users) > first {
+		users = users[:first]
+		conn.hasNextPage = true
+	}
+	for _, user := range users {
+		conn.edges = append(conn.edges, &userEdgeResolver{user: user})
+	}
+	return conn, nil
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	if err := requireAPIKeyScope(ctx, models.APIKeyScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: args.Input.Username,
+		Email:    args.Input.Email,
+		Password: args.Input.Password,
+	}
+	if err := user.Validate(); err != nil {
+		return nil, newError(codeBadUserInput, error_handler.ParseValidationErrors(err))
+	}
+
+	id, err := r.users.CreateUser(ctx, user)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+
+	return r.reload(ctx, id)
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateUserInput
+}) (*userResolver, error) {
+	if err := requireAPIKeyScope(ctx, models.APIKeyScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireSelfOrAPIKey(ctx, id); err != nil {
+		return nil, err
+	}
+
+	input := &models.UserUpdate{
+		ID:       id,
+		Username: deref(args.Input.Username),
+		Email:    deref(args.Input.Email),
+	}
+	if err := input.Validate(); err != nil {
+		return nil, newError(codeBadUserInput, error_handler.ParseValidationErrors(err))
+	}
+
+	if err := r.users.UpdateUser(ctx, input); err != nil {
+		return nil, toError(ctx, err)
+	}
+
+	return r.reload(ctx, id)
+}
+
+func (r *resolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := requireAPIKeyScope(ctx, models.APIKeyScopeUsersWrite); err != nil {
+		return false, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := requireSelfOrAPIKey(ctx, id); err != nil {
+		return false, err
+	}
+
+	if err := r.users.DeleteUser(ctx, id); err != nil {
+		return false, toError(ctx, err)
+	}
+
+	userLoaderFrom(ctx).Clear(ctx, id)
+	return true, nil
+}
+
+// reload читает пользователя после изменения в обход закэшированного в загрузчике значения
+func (r *resolver) reload(ctx context.Context, id int) (*userResolver, error) {
+	loader := userLoaderFrom(ctx)
+	loader.Clear(ctx, id)
+
+	user, err := loader.Load(ctx, id)()
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+type userResolver struct {
+	user models.UserResponse
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.user.ID))
+}
+
+func (r *userResolver) Username() string {
+	return r.user.Username
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+type userConnectionResolver struct {
+	edges       []*userEdgeResolver
+	hasNextPage bool
+}
+
+func (r *userConnectionResolver) Edges() []*userEdgeResolver {
+	return r.edges
+}
+
+func (r *userConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.edges) > 0 {
+		cursor := r.edges[len(r.edges)-1].Cursor()
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+type userEdgeResolver struct {
+	user models.UserResponse
+}
+
+func (r *userEdgeResolver) Cursor() string {
+	return encodeCursor(r.user.ID)
+}
+
+func (r *userEdgeResolver) Node() *userResolver {
+	return &userResolver{user: r.user}
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, newError(codeBadUserInput, "Invalid user ID format")
+	}
+	return n, nil
+}
+
+// Курсор - ID пользователя, непрозрачный для клиента
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
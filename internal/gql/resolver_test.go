@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/service/mocks"
+)
+
+func exec(t *testing.T, schema *Schema, ctx context.Context, query string) map[string]interface{} {
+	resp := schema.Exec(ctx, query, "", nil)
+
+	body, err := json.Marshal(resp)
+	assert.NoError(t, err)
+
+	var result map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &result))
+	return result
+}
+
+func TestUser_BatchesLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+
+	// Оба пользователя запрашиваются одним вызовом
+	mockUsers.EXPECT().GetUsersByIDs(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ids []int) ([]models.UserResponse, error) {
+			assert.ElementsMatch(t, []int{1, 2}, ids)
+			return []models.UserResponse{{ID: 1, Username: "one"}}, nil
+		})
+
+	result := exec(t, NewSchema(mockUsers), context.Background(),
+		`{ a: user(id: "1") { username } b: user(id: "2") { username } }`)
+
+	assert.Nil(t, result["errors"])
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"username": "one"},
+		"b": nil,
+	}, result["data"])
+}
+
+func TestUsers_Pagination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+
+	mockUsers.EXPECT().ListUserPage(gomock.Any(), models.UserFilter{Username: "jo"}, 0, 3).
+		Return([]models.UserResponse{{ID: 1}, {ID: 2}, {ID: 3}}, nil)
+
+	result := exec(t, NewSchema(mockUsers), context.Background(),
+		`{ users(filter: {username: "jo"}, first: 2) { edges { node { id } } pageInfo { hasNextPage endCursor } } }`)
+
+	assert.Nil(t, result["errors"])
+	users := result["data"].(map[string]interface{})["users"].(map[string]interface{})
+	assert.Len(t, users["edges"], 2)
+
+	pageInfo := users["pageInfo"].(map[string]interface{})
+	assert.Equal(t, true, pageInfo["hasNextPage"])
+	assert.Equal(t, encodeCursor(2), pageInfo["endCursor"])
+}
+
+func TestCreateUser_RequiresWriteScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+
+	ctx := WithAPIKeyScopes(context.Background(), []string{models.APIKeyScopeUsersRead})
+	result := exec(t, NewSchema(mockUsers), ctx,
+		`mutation { createUser(input: {username: "testuser", email: "test@example.com", password: "password123"}) { id } }`)
+
+	errs := result["errors"].([]interface{})
+	assert.Len(t, errs, 1)
+	extensions := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	assert.Equal(t, codeForbidden, extensions["code"])
+}
+
+func TestDeleteUser_OnlySelf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().DeleteUser(gomock.Any(), 5).Return(nil)
+	schema := NewSchema(mockUsers)
+	ctx := WithUserID(context.Background(), 5)
+
+	// Пользователь не удаляет чужую запись
+	result := exec(t, schema, ctx, `mutation { deleteUser(id: "6") }`)
+	errs := result["errors"].([]interface{})
+	assert.Len(t, errs, 1)
+	extensions := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	assert.Equal(t, codeForbidden, extensions["code"])
+
+	result = exec(t, schema, ctx, `mutation { deleteUser(id: "5") }`)
+	assert.Nil(t, result["errors"])
+	assert.Equal(t, true, result["data"].(map[string]interface{})["deleteUser"])
+}
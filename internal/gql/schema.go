@@ -0,0 +1,54 @@
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"runtime/debug"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"simple_crud_go/internal/service"
+	"simple_crud_go/pkg/logging"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Ограничения на сложность запроса, размер запроса ограничивает HTTP-обработчик
+const (
+	maxDepth       = 10
+	maxParallelism = 10
+)
+
+// Schema - GraphQL-схема API пользователей поверх service.UserService
+type Schema struct {
+	schema *graphql.Schema
+	users  service.UserService
+}
+
+// NewSchema разбирает схему и связывает ее с резолверами, ошибка в схеме - ошибка программиста
+func NewSchema(users service.UserService) *Schema {
+	schema := graphql.MustParseSchema(schemaSDL, &resolver{users: users},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxParallelism),
+		graphql.Logger(panicLogger{}),
+	)
+	return &Schema{schema: schema, users: users}
+}
+
+// Exec выполняет запрос; загрузчик пользователей создается на каждый запрос,
+// чтобы кэш не переживал запрос и не отдавал устаревшие данные
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]interface{}) *graphql.Response {
+	ctx = withUserLoader(ctx, newUserLoader(s.users))
+	return s.schema.Exec(ctx, query, operationName, variables)
+}
+
+// panicLogger пишет паники резолверов в логгер запроса
+type panicLogger struct{}
+
+func (panicLogger) LogPanic(ctx context.Context, value interface{}) {
+	logging.FromContext(ctx).
+		WithField("panic", value).
+		WithField("stack", string(debug.Stack())).
+		Error("Recovered from panic in GraphQL resolver")
+}
@@ -0,0 +1,46 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
+	"simple_crud_go/pkg/logging"
+)
+
+// toStatus переводит ошибку сервиса в статус gRPC, как это делают HTTP-обработчики
+func toStatus(ctx context.Context, err error) error {
+	var validationErrors models.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		return status.Error(codes.InvalidArgument, error_handler.ParseValidationErrors(err))
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "Deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "Request canceled")
+	}
+
+	code, constraint := error_handler.ErrorCode(err)
+	if code == "23503" && constraint == "users_tenant_id_fkey" {
+		return status.Error(codes.InvalidArgument, "Unknown tenant")
+	}
+	if code == "23505" {
+		switch constraint {
+		case "users_username_key":
+			return status.Error(codes.AlreadyExists, "Username is already exist")
+		case "users_email_key":
+			return status.Error(codes.AlreadyExists, "Email is already exist")
+		}
+	}
+
+	logging.FromContext(ctx).Errorf("gRPC call failed: %v", err)
+	return status.Error(codes.Internal, "Something went wrong")
+}
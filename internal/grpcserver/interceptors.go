@@ -0,0 +1,224 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	userv1 "simple_crud_go/api/user/v1"
+	"simple_crud_go/internal/db"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/middleware"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/logging"
+)
+
+// Метаданные gRPC передаются в нижнем регистре
+const (
+	requestIDMetadata = "x-request-id"
+	tenantMetadata    = "x-tenant-id"
+)
+
+// Области API-ключа, необходимые для методов
+var methodScopes = map[string]string{
+	userv1.UserService_CreateUser_FullMethodName: models.APIKeyScopeUsersWrite,
+	userv1.UserService_GetUser_FullMethodName:    models.APIKeyScopeUsersRead,
+	userv1.UserService_UpdateUser_FullMethodName: models.APIKeyScopeUsersWrite,
+	userv1.UserService_DeleteUser_FullMethodName: models.APIKeyScopeUsersWrite,
+	userv1.UserService_ListUsers_FullMethodName:  models.APIKeyScopeUsersRead,
+}
+
+type (
+	apiKeyIDKey     struct{}
+	apiKeyTenantKey struct{}
+)
+
+// requestIDInterceptor берет идентификатор вызова из метаданных x-request-id или, если его нет или он
+// не проходит ту же проверку, что и в HTTP, создает новый и привязывает к контексту логгер с этим идентификатором
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := firstMetadata(ctx, requestIDMetadata)
+		if !middleware.ValidRequestID(requestID) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				logging.FromContext(ctx).Errorf("Could not generate request ID: %v", err)
+				return nil, status.Error(codes.Internal, "Something went wrong")
+			}
+			requestID = hex.EncodeToString(b)
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+
+		entry := logrus.WithField("request_id", requestID)
+		return handler(logging.WithLogger(ctx, entry), req)
+	}
+}
+
+// dbRoutingInterceptor закрепляет чтения вызова за одной репликой, как middleware.DBRouting для HTTP
+func dbRoutingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(db.WithRouting(ctx), req)
+	}
+}
+
+// tenantInterceptor берет арендатора из API-ключа, метаданные x-tenant-id могут только совпадать с ним.
+// Вызовы без ключа (health, reflection) указывают арендатора метаданными, без них - арендатор по умолчанию
+func tenantInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		value := firstMetadata(ctx, tenantMetadata)
+
+		tenantID := tenant.DefaultID
+		if keyTenant, ok := ctx.Value(apiKeyTenantKey{}).(int); ok {
+			tenantID = keyTenant
+			if value != "" && value != strconv.Itoa(tenantID) {
+				return nil, status.Error(codes.PermissionDenied, "Tenant does not match the API key")
+			}
+		} else if value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				return nil, status.Error(codes.InvalidArgument, "Invalid x-tenant-id metadata")
+			}
+			tenantID = id
+		}
+		return handler(tenant.WithID(ctx, tenantID), req)
+	}
+}
+
+// loggingInterceptor пишет в лог каждый вызов, как middleware.Logger для HTTP
+func loggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		fields := logrus.Fields{
+			"method":  info.FullMethod,
+			"code":    code.String(),
+			"latency": time.Since(start).String(),
+		}
+		if apiKeyID, ok := ctx.Value(apiKeyIDKey{}).(int); ok {
+			fields["api_key_id"] = apiKeyID
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+
+		entry := logging.FromContext(ctx).WithFields(fields)
+		switch code {
+		case codes.OK:
+			entry.Info("gRPC call completed")
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			entry.Error("gRPC call completed")
+		default:
+			entry.Warn("gRPC call completed")
+		}
+		return resp, err
+	}
+}
+
+// recoveryInterceptor перехватывает панику в обработчике и возвращает codes.Internal
+func recoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logging.FromContext(ctx).
+					WithField("panic", rec).
+					WithField("stack", string(debug.Stack())).
+					Error("Recovered from panic")
+
+				err = status.Error(codes.Internal, "Something went wrong")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// apiKeyInterceptor проверяет API-ключ из метаданных authorization: ApiKey <ключ>.
+// Методы пользователей требуют ключ с нужной методу областью, без ключа вызов отклоняется
+// с codes.Unauthenticated, как и с недействительным ключом. Остальные методы (health, reflection)
+// выполняются анонимно
+func apiKeyInterceptor(apiKeys middleware.APIKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, requiresKey := methodScopes[info.FullMethod]
+
+		rawKey, ok := strings.CutPrefix(firstMetadata(ctx, "authorization"), "ApiKey ")
+		if !ok || rawKey == "" {
+			if requiresKey {
+				return nil, status.Error(codes.Unauthenticated, "API key required")
+			}
+			return handler(ctx, req)
+		}
+
+		key, err := apiKeys.AuthenticateAPIKey(ctx, rawKey)
+		if err != nil {
+			if errors.Is(err, models.ErrUnauthenticated) {
+				logging.FromContext(ctx).Warnf("API key rejected: %v", err)
+				return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+			}
+			logging.FromContext(ctx).Errorf("API key check failed: %v", err)
+			return nil, status.Error(codes.Internal, "Something went wrong")
+		}
+
+		if requiresKey && !slices.Contains(key.Scopes, scope) {
+			return nil, status.Error(codes.PermissionDenied, "API key does not have the required scope")
+		}
+
+		ctx = context.WithValue(ctx, apiKeyIDKey{}, key.ID)
+		return handler(context.WithValue(ctx, apiKeyTenantKey{}, key.TenantID), req)
+	}
+}
+
+// rateLimitInterceptor ограничивает вызовы по правилу group, как middleware.RateLimiter для HTTP.
+// Вызовы с API-ключом учитываются по ключу, если этого требует правило, остальные - по адресу клиента
+func rateLimitInterceptor(limiter *middleware.RateLimiter, group string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		result, limited := limiter.Take(ctx, group, func(key string) string {
+			if apiKeyID, ok := ctx.Value(apiKeyIDKey{}).(int); ok && key == "user" {
+				return "apikey:" + strconv.Itoa(apiKeyID)
+			}
+			return "ip:" + peerIP(ctx)
+		})
+		if limited && !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+			return nil, status.Error(codes.ResourceExhausted, "Too many requests")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP возвращает адрес клиента соединения без порта
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	userv1 "simple_crud_go/api/user/v1"
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/middleware"
+	"simple_crud_go/internal/service"
+)
+
+// Server - gRPC-сервер с API пользователей, стандартными health и reflection
+type Server struct {
+	grpcServer *grpc.Server
+	health     *health.Server
+	addr       string
+}
+
+// NewServer создает gRPC-сервер поверх тех же сервисов, что и HTTP-обработчики.
+// Вызовы ограничиваются тем же limiter и правилом users, что и HTTP-маршруты пользователей
+func NewServer(cfg *configs.GRPCConfig, services *service.Services, limiter *middleware.RateLimiter) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestIDInterceptor(),
+			dbRoutingInterceptor(),
+			loggingInterceptor(),
+			recoveryInterceptor(),
+			apiKeyInterceptor(services),
+			rateLimitInterceptor(limiter, "users"),
+			tenantInterceptor(),
+		),
+	)
+
+	userv1.RegisterUserServiceServer(grpcServer, newUserServer(services))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus(userv1.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(grpcServer)
+
+	return &Server{
+		grpcServer: grpcServer,
+		health:     healthServer,
+		addr:       net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+	}
+}
+
+// ListenAndServe принимает соединения до остановки сервера, штатная остановка ошибкой не считается
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Starting gRPC server on %s", s.addr)
+	err = s.grpcServer.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown переводит health в NOT_SERVING и дожидается завершения активных вызовов в пределах ctx,
+// по истечении ctx оставшиеся соединения закрываются принудительно
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		logger.Info("gRPC server gracefully stopped")
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
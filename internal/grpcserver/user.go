@@ -0,0 +1,141 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	userv1 "simple_crud_go/api/user/v1"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
+)
+
+// Размер страницы ListUsers
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// userServer реализует user.v1.UserService поверх service.UserService
+type userServer struct {
+	userv1.UnimplementedUserServiceServer
+	users service.UserService
+}
+
+func newUserServer(users service.UserService) *userServer {
+	return &userServer{users: users}
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.CreateUserResponse, error) {
+	user := &models.User{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := user.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, error_handler.ParseValidationErrors(err))
+	}
+
+	id, err := s.users.CreateUser(ctx, user)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userv1.CreateUserResponse{Id: int64(id)}, nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+	user, err := s.users.GetUserById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userv1.GetUserResponse{User: &userv1.User{
+		Id:       int64(user.ID),
+		Username: user.Username,
+		Email:    user.Email,
+	}}, nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.UpdateUserResponse, error) {
+	input := &models.UserUpdate{
+		ID:       int(req.GetId()),
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+	}
+	if err := input.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, error_handler.ParseValidationErrors(err))
+	}
+
+	if err := s.users.UpdateUser(ctx, input); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userv1.UpdateUserResponse{}, nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+	if err := s.users.DeleteUser(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userv1.DeleteUserResponse{}, nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	afterID, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "page_token is invalid")
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	users, err := s.users.ListUserPage(ctx, models.UserFilter{}, afterID, pageSize+1)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &userv1.ListUsersResponse{}
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		resp.NextPageToken = encodePageToken(users[len(users)-1].ID)
+	}
+	for _, user := range users {
+		resp.Users = append(resp.Users, &userv1.User{
+			Id:       int64(user.ID),
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	}
+
+	return resp, nil
+}
+
+// Токен страницы - ID последнего пользователя предыдущей страницы, непрозрачный для клиента
+func encodePageToken(lastID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(lastID)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
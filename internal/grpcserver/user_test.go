@@ -0,0 +1,219 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	userv1 "simple_crud_go/api/user/v1"
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/middleware"
+	"simple_crud_go/internal/service"
+	"simple_crud_go/internal/service/mocks"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/ratelimit"
+)
+
+// newTestClient поднимает сервер в памяти и возвращает клиент к нему.
+// extra добавляются в цепочку после проверки API-ключа
+func newTestClient(t *testing.T, services *service.Services, extra ...grpc.UnaryServerInterceptor) userv1.UserServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	interceptors := append([]grpc.UnaryServerInterceptor{recoveryInterceptor(), apiKeyInterceptor(services)}, extra...)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	userv1.RegisterUserServiceServer(srv, newUserServer(services))
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return userv1.NewUserServiceClient(conn)
+}
+
+const testAPIKey = "ak_test.secret"
+
+// mockAPIKeys принимает testAPIKey арендатора tenantID со всеми областями
+func mockAPIKeys(ctrl *gomock.Controller, tenantID int) *mocks.MockAPIKeyService {
+	mockKeys := mocks.NewMockAPIKeyService(ctrl)
+	mockKeys.EXPECT().AuthenticateAPIKey(gomock.Any(), testAPIKey).Return(models.APIKey{
+		ID:       1,
+		TenantID: tenantID,
+		Scopes:   []string{models.APIKeyScopeUsersRead, models.APIKeyScopeUsersWrite},
+	}, nil).AnyTimes()
+	return mockKeys
+}
+
+// withAPIKey добавляет testAPIKey в метаданные вызова
+func withAPIKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "ApiKey "+testAPIKey)
+}
+
+func TestGetUser_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().GetUserById(gomock.Any(), 7).Return(models.User{}, pgx.ErrNoRows)
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)})
+
+	_, err := client.GetUser(withAPIKey(context.Background()), &userv1.GetUserRequest{Id: 7})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCreateUser_InvalidArgument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)})
+
+	_, err := client.CreateUser(withAPIKey(context.Background()), &userv1.CreateUserRequest{Username: "ab", Password: "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateUser_AlreadyExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+		Return(0, &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)})
+
+	_, err := client.CreateUser(withAPIKey(context.Background()), &userv1.CreateUserRequest{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "Str0ng!Passw0rd",
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Equal(t, "Email is already exist", status.Convert(err).Message())
+}
+
+func TestListUsers_Pagination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().ListUserPage(gomock.Any(), models.UserFilter{}, 0, 3).Return([]models.UserResponse{
+		{ID: 1, Username: "one"}, {ID: 2, Username: "two"}, {ID: 3, Username: "three"},
+	}, nil)
+	mockUsers.EXPECT().ListUserPage(gomock.Any(), models.UserFilter{}, 2, 3).Return([]models.UserResponse{
+		{ID: 3, Username: "three"},
+	}, nil)
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)})
+	ctx := withAPIKey(context.Background())
+
+	first, err := client.ListUsers(ctx, &userv1.ListUsersRequest{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, first.Users, 2)
+	assert.NotEmpty(t, first.NextPageToken)
+
+	second, err := client.ListUsers(ctx, &userv1.ListUsersRequest{PageSize: 2, PageToken: first.NextPageToken})
+	assert.NoError(t, err)
+	assert.Len(t, second.Users, 1)
+	assert.Empty(t, second.NextPageToken)
+
+	_, err = client.ListUsers(ctx, &userv1.ListUsersRequest{PageToken: "!"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockKeys := mocks.NewMockAPIKeyService(ctrl)
+	mockKeys.EXPECT().AuthenticateAPIKey(gomock.Any(), "ak_unknown.secret").Return(models.APIKey{}, service.ErrInvalidAPIKey)
+
+	client := newTestClient(t, &service.Services{APIKeyService: mockKeys})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "ApiKey ak_unknown.secret")
+	_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAPIKey_Required(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := newTestClient(t, &service.Services{UserService: mocks.NewMockUserService(ctrl)})
+
+	_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTenant_FromAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().GetUserById(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, id int) (models.User, error) {
+		assert.Equal(t, 5, tenant.FromContext(ctx))
+		return models.User{ID: id}, nil
+	}).Times(2)
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 5)}, tenantInterceptor())
+
+	_, err := client.GetUser(withAPIKey(context.Background()), &userv1.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+
+	ctx := metadata.AppendToOutgoingContext(withAPIKey(context.Background()), tenantMetadata, "5")
+	_, err = client.GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+
+	// Ключ одного арендатора не дает доступа к другому
+	ctx = metadata.AppendToOutgoingContext(withAPIKey(context.Background()), tenantMetadata, "1")
+	_, err = client.GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRateLimit_ResourceExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().GetUserById(gomock.Any(), 1).Return(models.User{ID: 1}, nil)
+
+	limiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), &configs.RateLimitConfig{
+		Enabled: true,
+		Rules:   map[string]configs.RateLimitRule{"users": {Requests: 1, Period: time.Minute}},
+	})
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)}, rateLimitInterceptor(limiter, "users"))
+
+	_, err := client.GetUser(withAPIKey(context.Background()), &userv1.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.GetUser(withAPIKey(context.Background()), &userv1.GetUserRequest{Id: 1}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+}
+
+func TestRequestID_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserService(ctrl)
+	mockUsers.EXPECT().GetUserById(gomock.Any(), 1).Return(models.User{ID: 1}, nil).Times(2)
+
+	client := newTestClient(t, &service.Services{UserService: mockUsers, APIKeyService: mockAPIKeys(ctrl, 1)}, requestIDInterceptor())
+
+	requestID := func(value string) string {
+		var header metadata.MD
+		ctx := metadata.AppendToOutgoingContext(withAPIKey(context.Background()), requestIDMetadata, value)
+		_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 1}, grpc.Header(&header))
+		assert.NoError(t, err)
+		return header.Get(requestIDMetadata)[0]
+	}
+
+	assert.Equal(t, "abc-123", requestID("abc-123"))
+
+	// Идентификатор с недопустимыми символами заменяется новым
+	replaced := requestID("bad id")
+	assert.NotEqual(t, "bad id", replaced)
+	assert.Len(t, replaced, 32)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/pkg/logging"
+)
+
+// LogLevelRequest - запрос на изменение уровня логирования
+type LogLevelRequest struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration"` // Например "15m", пусто - без автоматического возврата
+}
+
+// LogLevelResponse - текущее состояние уровня логирования
+type LogLevelResponse struct {
+	Level         string     `json:"level"`
+	DefaultLevel  string     `json:"default_level"`
+	OverrideUntil *time.Time `json:"override_until,omitempty"`
+}
+
+// GetLogLevel возвращает текущий уровень логирования
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   newLogLevelResponse(logging.CurrentLevelState()),
+	})
+}
+
+// SetLogLevel меняет уровень логирования, при указании duration - временно
+func (h *Handler) SetLogLevel(c *gin.Context) {
+	var input LogLevelRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	level, err := logrus.ParseLevel(input.Level)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Level is invalid", err)
+		return
+	}
+
+	log := logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
+		"level":     level.String(),
+		"client_ip": c.ClientIP(),
+	})
+
+	if input.Duration == "" {
+		logging.SetLevel(level)
+		log.Warn("Log level changed")
+	} else {
+		duration, err := time.ParseDuration(input.Duration)
+		if err == nil && duration <= 0 {
+			err = errors.New("duration must be positive")
+		}
+		if err != nil {
+			NewErrorResponse(c, http.StatusBadRequest, "Duration is invalid", err)
+			return
+		}
+
+		logging.OverrideLevel(level, duration)
+		log.WithField("duration", duration.String()).Warn("Log level temporarily overridden")
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   newLogLevelResponse(logging.CurrentLevelState()),
+	})
+}
+
+func newLogLevelResponse(state logging.LevelState) LogLevelResponse {
+	response := LogLevelResponse{
+		Level:        state.Level.String(),
+		DefaultLevel: state.DefaultLevel.String(),
+	}
+	if !state.OverrideUntil.IsZero() {
+		response.OverrideUntil = &state.OverrideUntil
+	}
+	return response
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+)
+
+// CreateAPIKey godoc
+// @Summary      Create API key
+// @Description  Create an API key for a tenant. The full key is shown only in this response. Requires the admin token
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        key body models.APIKeyInput true "Key name, scopes and expiry"
+// @Success      201 {object} SuccessResponse{data=models.APIKeyCreated}
+// @Failure      400 {object} ErrorResponse "Invalid input format or unknown tenant"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      403 {object} ErrorResponse "Admin access is disabled"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /admin/api-keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var input models.APIKeyInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		validationMessage := error_handler.ParseValidationErrors(err)
+		NewErrorResponse(c, http.StatusBadRequest, validationMessage, err)
+		return
+	}
+
+	key, err := h.services.CreateAPIKey(c.Request.Context(), &input)
+	if err != nil {
+		var validationErrors models.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			NewErrorResponse(c, http.StatusBadRequest, error_handler.ParseValidationErrors(err), err)
+			return
+		}
+		if code, constraint := error_handler.ErrorCode(err); code == ForeignKeyViolation && constraint == "api_keys_tenant_id_fkey" {
+			NewErrorResponse(c, http.StatusBadRequest, "Unknown tenant", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   key,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Get all API keys without their secrets. Requires the admin token
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} SuccessResponse{data=[]models.APIKey}
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      403 {object} ErrorResponse "Admin access is disabled"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /admin/api-keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.services.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   keys,
+	})
+}
+
+// RotateAPIKey godoc
+// @Summary      Rotate API key
+// @Description  Issue a new secret for the key, the old secret stops working immediately. Requires the admin token
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "API key ID"
+// @Success      200 {object} SuccessResponse{data=models.APIKeyCreated}
+// @Failure      400 {object} ErrorResponse "Invalid API key ID format"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      403 {object} ErrorResponse "Admin access is disabled"
+// @Failure      404 {object} ErrorResponse "API key not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /admin/api-keys/{id}/rotate [post]
+func (h *Handler) RotateAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid API key ID format", err)
+		return
+	}
+
+	key, err := h.services.RotateAPIKey(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			NewErrorResponse(c, http.StatusNotFound, "API key not found", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   key,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API key
+// @Description  Revoke an API key. Requires the admin token
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "API key ID"
+// @Success      200 {object} SuccessResponse{data=string} "API key revoked successfully"
+// @Failure      400 {object} ErrorResponse "Invalid API key ID format"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      403 {object} ErrorResponse "Admin access is disabled"
+// @Failure      404 {object} ErrorResponse "API key not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /admin/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid API key ID format", err)
+		return
+	}
+
+	if err := h.services.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			NewErrorResponse(c, http.StatusNotFound, "API key not found", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   "API key revoked successfully",
+	})
+}
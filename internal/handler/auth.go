@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
+)
+
+// Login godoc
+// @Summary      Log in
+// @Description  Check the username and password, and the two-factor code when TOTP is enabled, and issue an access token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        credentials body models.LoginInput true "Username, password and an optional TOTP or recovery code"
+// @Success      200 {object} SuccessResponse{data=models.TokenResponse}
+// @Failure      400 {object} ErrorResponse "Invalid input format"
+// @Failure      401 {object} ErrorResponse "Invalid username, password or two-factor code, or two-factor code required"
+// @Failure      429 {object} ErrorResponse "Too many failed login attempts, see Retry-After"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var input models.LoginInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		validationMessage := error_handler.ParseValidationErrors(err)
+		NewErrorResponse(c, http.StatusBadRequest, validationMessage, err)
+		return
+	}
+
+	token, err := h.services.Login(c.Request.Context(), &input, clientInfo(c))
+	if err != nil {
+		var lockedErr *service.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			NewErrorResponse(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later", err)
+		case errors.Is(err, service.ErrInvalidCredentials):
+			NewErrorResponse(c, http.StatusUnauthorized, "Invalid username or password", err)
+		case errors.Is(err, service.ErrTOTPRequired):
+			NewErrorResponse(c, http.StatusUnauthorized, "Two-factor code required", err)
+		case errors.Is(err, service.ErrInvalidTOTPCode):
+			NewErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code", err)
+		default:
+			NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   token,
+	})
+}
+
+// UnlockUser godoc
+// @Summary      Unlock user login
+// @Description  Lift the login lockout of a user after repeated failed attempts. Requires the admin token
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} SuccessResponse{data=string} "User unlocked successfully"
+// @Failure      400 {object} ErrorResponse "Invalid user ID format"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      403 {object} ErrorResponse "Admin access is disabled"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /admin/users/{id}/unlock [post]
+func (h *Handler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid user ID format", err)
+		return
+	}
+
+	if err := h.services.UnlockAccount(c.Request.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   "User unlocked successfully",
+	})
+}
+
+// clientInfo возвращает данные клиента для сессии
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/service"
+)
+
+// Запас на заголовки и границы multipart сверх размера самого изображения
+const multipartOverhead = 64 << 10
+
+// UploadAvatar godoc
+// @Summary      Upload user avatar
+// @Description  Replace the user's avatar with a JPEG, PNG, GIF or WebP image. Square thumbnails are generated automatically
+// @Tags         users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id     path     string true "User ID"
+// @Param        avatar formData file   true "Avatar image"
+// @Success      200 {object} SuccessResponse{data=models.Avatar}
+// @Failure      400 {object} ErrorResponse "Invalid image"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Avatar of another user"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      413 {object} ErrorResponse "Image is too large"
+// @Failure      415 {object} ErrorResponse "Unsupported image type"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/{id}/avatar [put]
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid user ID format", err)
+		return
+	}
+
+	// Ограничиваем тело запроса, чтобы большой файл не попал во временные файлы multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Avatar.MaxBytes+multipartOverhead)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			NewErrorResponse(c, http.StatusRequestEntityTooLarge, "Image is too large", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusBadRequest, "Avatar file is required", err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+	defer file.Close()
+
+	avatar, err := h.services.SetAvatar(c.Request.Context(), id, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			NewErrorResponse(c, http.StatusRequestEntityTooLarge, "Image is too large", err)
+		case errors.Is(err, service.ErrAvatarUnsupportedType):
+			NewErrorResponse(c, http.StatusUnsupportedMediaType, "Unsupported image type, use JPEG, PNG, GIF or WebP", err)
+		case errors.Is(err, service.ErrAvatarInvalid):
+			NewErrorResponse(c, http.StatusBadRequest, "Invalid image", err)
+		default:
+			NewErrorResponse(c, http.StatusInternalServerError, "Failed to update avatar", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   avatar,
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/db/models"
+)
+
+// notModified выставляет заголовки ETag, Last-Modified и Cache-Control и отвечает 304,
+// если копия клиента актуальна. If-None-Match имеет приоритет над If-Modified-Since (RFC 9110)
+func (h *Handler) notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	if h.cfg.HTTPCache.CacheControl != "" {
+		c.Header("Cache-Control", h.cfg.HTTPCache.CacheControl)
+	}
+
+	fresh := false
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		fresh = etagMatches(inm, etag)
+	} else if ims, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil {
+		// Last-Modified передается с точностью до секунды
+		fresh = !lastModified.Truncate(time.Second).After(ims)
+	}
+
+	if fresh {
+		c.AbortWithStatus(http.StatusNotModified)
+	}
+	return fresh
+}
+
+// etagMatches сравнивает ETag слабым сравнением, как требуется для If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// userETag меняется при изменении данных пользователя
+func userETag(user models.User) string {
+	return newETag(fmt.Sprintf("user:%d:%d", user.ID, user.UpdatedAt.UnixMicro()))
+}
+
+// userListETag меняется при добавлении, изменении и удалении пользователей
+func userListETag(version models.UserListVersion) string {
+	return newETag(fmt.Sprintf("users:%d:%d", version.Count, version.LastModified.UnixMicro()))
+}
+
+func newETag(version string) string {
+	sum := sha256.Sum256([]byte(version))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
@@ -1,32 +1,76 @@
 package error_handler
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"simple_crud_go/internal/db/models"
 )
 
 func ParseValidationErrors(err error) string {
+	// Нарушения правил, проверяемых в сервисном слое (например, политики паролей)
+	var serviceErrors models.ValidationErrors
+	if errors.As(err, &serviceErrors) {
+		var errorMessages []string
+		for _, fe := range serviceErrors {
+			errorMessages = append(errorMessages, fieldMessage(fe.Field, fe.Tag, fe.Param))
+		}
+		return strings.Join(errorMessages, "; ")
+	}
+
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		var errorMessages []string
 		for _, ve := range validationErrors {
 			field := ve.Field() // Имя поля
 			tag := ve.Tag()     // Тег валидации, например, "required" или "min"
-			switch tag {
-			case "required":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s is required", field))
-			case "email":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s must be a valid email", field))
-			case "min":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s must be at least %s characters", field, ve.Param()))
-			case "max":
-				errorMessages = append(errorMessages, fmt.Sprintf("%s must not exceed %s characters", field, ve.Param()))
-			default:
-				errorMessages = append(errorMessages, fmt.Sprintf("%s is invalid", field))
-			}
+			errorMessages = append(errorMessages, fieldMessage(field, tag, ve.Param()))
 		}
 		return strings.Join(errorMessages, "; ")
 	}
 	return "Invalid input data"
 }
+
+// fieldMessage формирует текст ошибки для поля по тегу правила
+func fieldMessage(field, tag, param string) string {
+	switch tag {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", field, param)
+	case "max":
+		return fmt.Sprintf("%s must not exceed %s characters", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, param)
+	case "e164":
+		return fmt.Sprintf("%s must be a phone number in E.164 format, e.g. +14155552671", field)
+	case "bcp47_language_tag":
+		return fmt.Sprintf("%s must be a BCP 47 language tag, e.g. en-US", field)
+	case "timezone":
+		return fmt.Sprintf("%s must be an IANA time zone, e.g. Europe/Berlin", field)
+	case models.TagMaxJSONBytes:
+		return fmt.Sprintf("%s must not exceed %s bytes as JSON", field, param)
+	case models.TagFuture:
+		return fmt.Sprintf("%s must be in the future", field)
+	case models.TagPasswordMaxBytes:
+		return fmt.Sprintf("%s must not exceed %s bytes", field, param)
+	case models.TagPasswordUpper:
+		return fmt.Sprintf("%s must contain an uppercase letter", field)
+	case models.TagPasswordLower:
+		return fmt.Sprintf("%s must contain a lowercase letter", field)
+	case models.TagPasswordDigit:
+		return fmt.Sprintf("%s must contain a digit", field)
+	case models.TagPasswordSpecial:
+		return fmt.Sprintf("%s must contain a special character", field)
+	case models.TagPasswordUserInfo:
+		return fmt.Sprintf("%s must not contain the username or email", field)
+	case models.TagPasswordBreached:
+		return fmt.Sprintf("%s has appeared in a data breach, choose a different one", field)
+	default:
+		return fmt.Sprintf("%s is invalid", field)
+	}
+}
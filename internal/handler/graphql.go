@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/gql"
+	"simple_crud_go/internal/middleware"
+)
+
+// graphQLRequest - тело запроса GraphQL по HTTP
+type graphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Предельный размер тела запроса GraphQL, число полей в запросе растет вместе с ним
+const maxGraphQLBodyBytes = 64 << 10
+
+// GraphQL выполняет запрос к GraphQL-схеме пользователей.
+// Ошибки выполнения возвращаются в поле errors ответа со статусом 200, как принято в GraphQL.
+func (h *Handler) GraphQL(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBodyBytes)
+
+	var input graphQLRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			NewErrorResponse(c, http.StatusRequestEntityTooLarge, "Query is too large", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, ok := c.Get(middleware.APIKeyIDKey); ok {
+		ctx = gql.WithAPIKeyScopes(ctx, c.GetStringSlice(middleware.APIKeyScopesKey))
+	}
+	if userID, ok := c.Get(middleware.UserIDKey); ok {
+		ctx = gql.WithUserID(ctx, userID.(int))
+	}
+
+	c.JSON(http.StatusOK, h.graphql.Exec(ctx, input.Query, input.OperationName, input.Variables))
+}
+
+// Путь к скриптам и стилям страницы GraphQL Playground
+const graphQLPlaygroundAssets = "/graphql/assets"
+
+// GraphQLPlayground отдает страницу для ручных запросов к GraphQL, доступна только в режиме отладки
+func (h *Handler) GraphQLPlayground(c *gin.Context) {
+	page, err := gql.Playground("/graphql", graphQLPlaygroundAssets)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
@@ -1,39 +1,189 @@
 package handler
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	_ "simple_crud_go/docs"
 
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/gql"
+	"simple_crud_go/internal/middleware"
 	"simple_crud_go/internal/service"
 )
 
 type Handler struct {
-	services service.UserService
+	services  *service.Services
+	cfg       *configs.Config
+	readiness ReadinessProbe
+	limiter   *middleware.RateLimiter
+	graphql   *gql.Schema
 }
 
-func NewHandler(services service.UserService) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Services, cfg *configs.Config, readiness ReadinessProbe, limiter *middleware.RateLimiter) *Handler {
+	return &Handler{
+		services:  services,
+		cfg:       cfg,
+		readiness: readiness,
+		limiter:   limiter,
+		graphql:   gql.NewSchema(services),
+	}
 }
 
 // InitRouters инициализирует маршруты приложения
 func (h *Handler) InitRouters() *gin.Engine {
 	router := gin.New()
 
+	// Заголовкам с адресом клиента доверяем только от настроенных прокси,
+	// иначе клиент может подменить адрес для ограничения частоты запросов и блокировки входа
+	if err := router.SetTrustedProxies(h.cfg.Server.TrustedProxies); err != nil {
+		logrus.Errorf("Invalid trusted proxies, client address headers are ignored: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+	if len(h.cfg.Server.RemoteIPHeaders) > 0 {
+		router.RemoteIPHeaders = h.cfg.Server.RemoteIPHeaders
+	}
+
+	// Идентификатор запроса, клиентский сертификат, логирование и восстановление после паники
+	router.Use(middleware.RequestID(), middleware.ClientCert(), middleware.Logger(), middleware.Recovery())
+
+	// Чтения запроса закрепляются за одной репликой
+	router.Use(middleware.DBRouting())
+
+	// Проверки состояния для оркестратора
+	router.GET("/health/live", h.Liveness)
+	router.GET("/health/ready", h.Readiness)
+
+	// Метрики для Prometheus, доступ как к /admin/*
+	router.GET("/metrics", middleware.AdminAuth(&h.cfg.Admin), gin.WrapH(promhttp.Handler()))
+
+	// Файлы из локального хранилища (аватары). Ключи файлов не переиспользуются, поэтому файлы кэшируются надолго.
+	// Список файлов каталога не отдается, иначе по нему можно перебрать аватары всех пользователей
+	if h.cfg.BlobStore.Backend == "local" {
+		media := router.Group(h.cfg.BlobStore.Local.BaseURL, func(c *gin.Context) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			c.Header("X-Content-Type-Options", "nosniff")
+		})
+		media.StaticFS("/", gin.Dir(h.cfg.BlobStore.Local.Dir, false))
+	}
+
 	// Роут для Swagger-документации
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Роуты для пользователя
-	user := router.Group("/user")
-	{
-		user.POST("/", h.CreateUser)
-		user.GET("/:id", h.GetUserByID)
-		user.PUT("/:id", h.UpdateUser)
-		user.DELETE("/:id", h.DeleteUser)
-		user.GET("/", h.ListUser)
+	// GraphQL API пользователей, как и REST, требует токен или API-ключ. Области API-ключа проверяются в резолверах
+	graphql := router.Group("/graphql", h.authenticate()...)
+	graphql.POST("", middleware.RequireCaller(), h.limiter.Limit("users"), h.GraphQL)
+
+	// Страница GraphQL Playground только в режиме отладки, ее скрипты встроены в бинарник
+	if gin.Mode() == gin.DebugMode {
+		router.GET("/graphql", h.GraphQLPlayground)
+		router.StaticFS(graphQLPlaygroundAssets, gql.PlaygroundAssets())
+	}
+
+	// Версии API монтируются под /api/<версия> и работают параллельно
+	api := router.Group("/api")
+	for _, version := range h.apiVersions() {
+		version.register(api.Group("/" + version.name))
+	}
+
+	// Старые маршруты без префикса - устаревший псевдоним v1
+	if h.cfg.API.LegacyRoutes {
+		sunset, _ := time.Parse(time.DateOnly, h.cfg.API.LegacySunset)
+		h.registerV1(router.Group("", middleware.Deprecated(sunset, "/api/v1")))
 	}
 
 	return router
 }
+
+// authenticate определяет пользователя по токену доступа или сервис по API-ключу и арендатора запроса,
+// все запросы к пользователям ограничены им. Административные маршруты проверяют свой токен без них
+func (h *Handler) authenticate() gin.HandlersChain {
+	return gin.HandlersChain{
+		middleware.Authenticate(h.services, h.services),
+		middleware.ResolveTenant(),
+	}
+}
+
+// apiVersion - набор маршрутов одной версии API
+type apiVersion struct {
+	name     string
+	register func(rg *gin.RouterGroup)
+}
+
+// apiVersions перечисляет поддерживаемые версии API. Новая версия добавляется своим методом регистрации,
+// обработчики неизменных маршрутов переиспользуются
+func (h *Handler) apiVersions() []apiVersion {
+	return []apiVersion{
+		{name: "v1", register: h.registerV1},
+	}
+}
+
+// registerV1 регистрирует маршруты API v1
+func (h *Handler) registerV1(rg *gin.RouterGroup) {
+	users := rg.Group("/user", h.authenticate()...)
+	read := middleware.RequireAPIKeyScope(models.APIKeyScopeUsersRead)
+	write := middleware.RequireAPIKeyScope(models.APIKeyScopeUsersWrite)
+	// Пользователь меняет только свою запись, сервис - любую запись арендатора ключа
+	self := middleware.RequireSelfOrAPIKey()
+
+	// Регистрация доступна и анонимно, арендатор выбирается заголовком X-Tenant-ID
+	users.POST("/", write, h.limiter.Limit("user_create"), h.CreateUser)
+
+	// Остальные роуты для пользователя доступны пользователю с токеном или сервису с API-ключом
+	user := users.Group("", middleware.RequireCaller(), h.limiter.Limit("users"))
+	{
+		user.GET("/:id", read, h.GetUserByID)
+		user.GET("/by-username/:username", read, h.GetUserByUsername)
+		user.GET("/by-email", read, h.GetUserByEmail)
+		user.POST("/lookup", read, h.LookupUsers)
+		user.PUT("/:id", write, self, h.UpdateUser)
+		user.PUT("/:id/avatar", write, self, h.UploadAvatar)
+		user.DELETE("/:id", write, self, h.DeleteUser)
+		user.GET("/", read, h.ListUser)
+
+		// Сессии пользователя, доступны только ему самому
+		user.GET("/:id/sessions", middleware.RequireAuth(), h.ListSessions)
+		user.DELETE("/:id/sessions", middleware.RequireAuth(), h.RevokeAllSessions)
+		user.DELETE("/:id/sessions/:sid", middleware.RequireAuth(), h.RevokeSession)
+	}
+
+	// Роуты аутентификации
+	auth := rg.Group("/auth", h.authenticate()...)
+	{
+		auth.POST("/login", h.limiter.Limit("login"), h.Login)
+
+		// Подключение TOTP доступно и с токеном администратора без второго фактора
+		totp := auth.Group("/totp", middleware.RequireAuth(models.ScopeTOTPEnroll), h.limiter.Limit("totp"))
+		{
+			totp.POST("/enroll", h.EnrollTOTP)
+			totp.POST("/confirm", h.ConfirmTOTP)
+		}
+
+		// Вход через провайдеров OpenID Connect и привязка внешних аккаунтов
+		oidc := auth.Group("/oidc/:provider", h.limiter.Limit("login"))
+		{
+			oidc.GET("/login", h.OIDCLogin)
+			oidc.GET("/callback", h.OIDCCallback)
+			oidc.POST("/link", middleware.RequireAuth(), h.OIDCLink)
+		}
+	}
+
+	// Административные роуты
+	admin := rg.Group("/admin", middleware.AdminAuth(&h.cfg.Admin))
+	{
+		admin.GET("/log-level", h.GetLogLevel)
+		admin.PUT("/log-level", h.SetLogLevel)
+		admin.POST("/users/:id/unlock", h.UnlockUser)
+
+		admin.POST("/api-keys", h.CreateAPIKey)
+		admin.GET("/api-keys", h.ListAPIKeys)
+		admin.POST("/api-keys/:id/rotate", h.RotateAPIKey)
+		admin.DELETE("/api-keys/:id", h.RevokeAPIKey)
+	}
+}
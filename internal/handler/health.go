@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessProbe сообщает, готово ли приложение принимать трафик
+type ReadinessProbe interface {
+	Ready() bool
+}
+
+// Liveness отвечает, что процесс жив
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   "alive",
+	})
+}
+
+// Readiness отвечает 503, когда приложение останавливается и не должно получать новые запросы
+func (h *Handler) Readiness(c *gin.Context) {
+	if h.readiness != nil && !h.readiness.Ready() {
+		NewErrorResponse(c, http.StatusServiceUnavailable, "Service is not ready", errors.New("readiness probe is failing"))
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   "ready",
+	})
+}
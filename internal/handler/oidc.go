@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/middleware"
+	"simple_crud_go/internal/service"
+)
+
+// oidcStateCookie привязывает вход к браузеру, который его начал
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin godoc
+// @Summary      Log in with an identity provider
+// @Description  Redirect to the sign-in page of the OpenID Connect provider
+// @Tags         auth
+// @Param        provider path string true "Provider name"
+// @Param        X-Tenant-ID header int false "Tenant to sign in to"
+// @Success      302 "Redirect to the provider"
+// @Failure      404 {object} ErrorResponse "Identity provider not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/oidc/{provider}/login [get]
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.services.OIDCAuthURL(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		h.oidcError(c, err)
+		return
+	}
+
+	h.setOIDCStateCookie(c, state)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCLink godoc
+// @Summary      Link an identity provider account
+// @Description  Return the sign-in page of the OpenID Connect provider for linking an external account to the current user
+// @Tags         auth
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Success      200 {object} SuccessResponse{data=models.OIDCAuthURLResponse}
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      404 {object} ErrorResponse "Identity provider not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/oidc/{provider}/link [post]
+func (h *Handler) OIDCLink(c *gin.Context) {
+	authURL, state, err := h.services.OIDCAuthURL(c.Request.Context(), c.Param("provider"), c.GetInt(middleware.UserIDKey))
+	if err != nil {
+		h.oidcError(c, err)
+		return
+	}
+
+	h.setOIDCStateCookie(c, state)
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   models.OIDCAuthURLResponse{AuthorizationURL: authURL},
+	})
+}
+
+// OIDCCallback godoc
+// @Summary      Identity provider callback
+// @Description  Complete sign-in or account linking after the provider redirects back. Sign-in returns an access token and creates the user on first login
+// @Tags         auth
+// @Produce      json
+// @Param        provider path  string true  "Provider name"
+// @Param        code     query string true  "Authorization code"
+// @Param        state    query string true  "Login state"
+// @Param        error    query string false "Error reported by the provider"
+// @Success      200 {object} SuccessResponse{data=models.OIDCResult}
+// @Failure      400 {object} ErrorResponse "Invalid or expired login state"
+// @Failure      401 {object} ErrorResponse "Identity provider authentication failed"
+// @Failure      403 {object} ErrorResponse "Accounts with two-factor authentication must sign in with a password"
+// @Failure      404 {object} ErrorResponse "Identity provider not found"
+// @Failure      409 {object} ErrorResponse "Email already exists or external account is linked to another user"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/oidc/{provider}/callback [get]
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		err := fmt.Errorf("provider error: %s: %s", providerErr, c.Query("error_description"))
+		NewErrorResponse(c, http.StatusUnauthorized, "Identity provider authentication failed", err)
+		return
+	}
+
+	state := c.Query("state")
+	cookie, err := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath(c), "", c.Request.TLS != nil, true)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid or expired login state", service.ErrInvalidOIDCState)
+		return
+	}
+
+	result, err := h.services.OIDCCallback(c.Request.Context(), c.Param("provider"), c.Query("code"), state, clientInfo(c))
+	if err != nil {
+		h.oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   result,
+	})
+}
+
+func (h *Handler) setOIDCStateCookie(c *gin.Context, state string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(h.cfg.Auth.OIDC.StateTTL.Seconds()), oidcCookiePath(c), "", c.Request.TLS != nil, true)
+}
+
+// oidcCookiePath ограничивает cookie маршрутами OIDC той версии API, через которую начат вход
+func oidcCookiePath(c *gin.Context) string {
+	path, _, _ := strings.Cut(c.FullPath(), "/:provider")
+	return path
+}
+
+func (h *Handler) oidcError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownOIDCProvider):
+		NewErrorResponse(c, http.StatusNotFound, "Identity provider not found", err)
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid or expired login state", err)
+	case errors.Is(err, service.ErrOIDCVerification):
+		NewErrorResponse(c, http.StatusUnauthorized, "Identity provider authentication failed", err)
+	case errors.Is(err, service.ErrOIDCEmailTaken):
+		NewErrorResponse(c, http.StatusConflict, "An account with this email already exists, sign in and link the provider", err)
+	case errors.Is(err, service.ErrOIDCIdentityLinked):
+		NewErrorResponse(c, http.StatusConflict, "External account is already linked to another user", err)
+	case errors.Is(err, service.ErrOIDCSecondFactor):
+		NewErrorResponse(c, http.StatusForbidden, "Accounts with two-factor authentication must sign in with a password", err)
+	default:
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+	}
+}
@@ -2,13 +2,16 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	logger "github.com/sirupsen/logrus"
+
+	"simple_crud_go/pkg/logging"
 )
 
 const (
 	StatusSuccess   = "success"
 	StatusError     = "failed"
 	UniqueViolation = "23505"
+	// ForeignKeyViolation - ссылка на несуществующую запись, например на неизвестного арендатора
+	ForeignKeyViolation = "23503"
 )
 
 // SuccessResponse - схема успешного ответа
@@ -23,7 +26,10 @@ type ErrorResponse struct {
 
 // NewErrorResponse обрабатывает ошибки и формирует JSON-ответ с ошибкой.
 func NewErrorResponse(c *gin.Context, statusCode int, message string, err error) {
-	logger.Error(err)
+	logging.FromContext(c.Request.Context()).
+		WithField("status", statusCode).
+		WithField("message", message).
+		Error(err)
 	// Формируем ответ, передавая объект ErrorResponse
 	c.AbortWithStatusJSON(statusCode, gin.H{
 		"status": StatusError,
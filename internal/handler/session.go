@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/middleware"
+)
+
+// ListSessions godoc
+// @Summary      List user sessions
+// @Description  Get the active sessions of the current user, the session of the request is marked as current
+// @Tags         sessions
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} SuccessResponse{data=[]models.Session}
+// @Failure      400 {object} ErrorResponse "Invalid user ID format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Sessions of another user"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/{id}/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, ok := h.sessionOwner(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.services.ListSessions(c.Request.Context(), userID, c.GetString(middleware.SessionIDKey))
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary      Revoke user session
+// @Description  End a session of the current user, its access tokens stop working
+// @Tags         sessions
+// @Produce      json
+// @Param        id  path string true "User ID"
+// @Param        sid path string true "Session ID"
+// @Success      200 {object} SuccessResponse{data=string} "Session revoked successfully"
+// @Failure      400 {object} ErrorResponse "Invalid user ID format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Sessions of another user"
+// @Failure      404 {object} ErrorResponse "Session not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/{id}/sessions/{sid} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, ok := h.sessionOwner(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.RevokeSession(c.Request.Context(), userID, c.Param("sid")); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			NewErrorResponse(c, http.StatusNotFound, "Session not found", err)
+			return
+		}
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   "Session revoked successfully",
+	})
+}
+
+// RevokeAllSessions godoc
+// @Summary      Log out everywhere
+// @Description  End all sessions of the current user, including the session of the request
+// @Tags         sessions
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} SuccessResponse{data=models.RevokedSessionsResponse}
+// @Failure      400 {object} ErrorResponse "Invalid user ID format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Sessions of another user"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/{id}/sessions [delete]
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userID, ok := h.sessionOwner(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.services.RevokeAllSessions(c.Request.Context(), userID)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   models.RevokedSessionsResponse{Revoked: revoked},
+	})
+}
+
+// sessionOwner проверяет, что пользователь управляет своими сессиями
+func (h *Handler) sessionOwner(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid user ID format", err)
+		return 0, false
+	}
+
+	if id != c.GetInt(middleware.UserIDKey) {
+		NewErrorResponse(c, http.StatusForbidden, "You can only manage your own sessions", errors.New("session owner mismatch"))
+		return 0, false
+	}
+
+	return id, true
+}
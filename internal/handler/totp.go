@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/middleware"
+	"simple_crud_go/internal/service"
+)
+
+// EnrollTOTP godoc
+// @Summary      Start TOTP enrolment
+// @Description  Create a TOTP secret for the current user and return the otpauth URI and a QR code. The secret takes effect after confirmation
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} SuccessResponse{data=models.TOTPEnrollment}
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Token scope does not permit this action"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      409 {object} ErrorResponse "Two-factor authentication is already enabled"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/totp/enroll [post]
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	enrollment, err := h.services.EnrollTOTP(c.Request.Context(), c.GetInt(middleware.UserIDKey))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+			NewErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled", err)
+		case errors.Is(err, pgx.ErrNoRows):
+			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+		default:
+			NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   enrollment,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirm TOTP enrolment
+// @Description  Enable TOTP with the first code from the authenticator app and return one-time recovery codes
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        code body models.TOTPConfirmInput true "Code from the authenticator app"
+// @Success      200 {object} SuccessResponse{data=models.RecoveryCodesResponse}
+// @Failure      400 {object} ErrorResponse "Invalid input format or two-factor code"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Token scope does not permit this action"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      409 {object} ErrorResponse "Two-factor authentication is already enabled or enrolment was not started"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /auth/totp/confirm [post]
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	var input models.TOTPConfirmInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		validationMessage := error_handler.ParseValidationErrors(err)
+		NewErrorResponse(c, http.StatusBadRequest, validationMessage, err)
+		return
+	}
+
+	codes, err := h.services.ConfirmTOTP(c.Request.Context(), c.GetInt(middleware.UserIDKey), input.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTOTPCode):
+			NewErrorResponse(c, http.StatusBadRequest, "Invalid two-factor code", err)
+		case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+			NewErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled", err)
+		case errors.Is(err, service.ErrTOTPNotEnrolled):
+			NewErrorResponse(c, http.StatusConflict, "Two-factor enrollment was not started", err)
+		case errors.Is(err, pgx.ErrNoRows):
+			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+		default:
+			NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   codes,
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -10,20 +11,24 @@ import (
 
 	"simple_crud_go/internal/db/models"
 	"simple_crud_go/internal/handler/error_handler"
+	"simple_crud_go/internal/service"
 )
 
 // CreateUser godoc
 // @Summary      Create a new user
-// @Description  Create a new user with the given details
+// @Description  Create a new user with the given details. Available without credentials for self-registration
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        user body models.User true "User Data"
+// @Param        X-Tenant-ID header int false "Tenant for anonymous registration, with credentials must match their tenant"
 // @Success      200 {object} SuccessResponse{data=models.UserResponse}
 // @Failure      400 {object} ErrorResponse "Invalid input format"
-// @Failure      403 {object} ErrorResponse "Username or email already exists"
+// @Failure      401 {object} ErrorResponse "Invalid credentials"
+// @Failure      403 {object} ErrorResponse "Username or email already exists, or tenant does not match the credentials"
+// @Failure      429 {object} ErrorResponse "Too many requests"
 // @Failure      500 {object} ErrorResponse "Internal server error"
-// @Router       / [post]
+// @Router       /user/ [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	var input models.User
 
@@ -43,14 +48,17 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	// Создаем пользователя
 	userID, err := h.services.CreateUser(c.Request.Context(), &input)
 	if err != nil {
+		var validationErrors models.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			NewErrorResponse(c, http.StatusBadRequest, error_handler.ParseValidationErrors(err), err)
+			return
+		}
+
 		code, constraint := error_handler.ErrorCode(err)
-		if code == UniqueViolation {
-			if constraint == "users_username_key" {
-				NewErrorResponse(c, http.StatusForbidden, "Username is already exist", err)
-			}
-			if constraint == "users_email_key" {
-				NewErrorResponse(c, http.StatusForbidden, "Email is already exist", err)
-			}
+		if message, ok := duplicateUserMessage(err); ok {
+			NewErrorResponse(c, http.StatusForbidden, message, err)
+		} else if code == ForeignKeyViolation && constraint == "users_tenant_id_fkey" {
+			NewErrorResponse(c, http.StatusBadRequest, "Unknown tenant", err)
 		} else {
 			NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
 		}
@@ -68,17 +76,39 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// duplicateUserMessage возвращает сообщение о занятом имени или email. Нарушения других
+// ограничений уникальности сообщением не считаются и отдаются как внутренняя ошибка
+func duplicateUserMessage(err error) (string, bool) {
+	code, constraint := error_handler.ErrorCode(err)
+	if code != UniqueViolation {
+		return "", false
+	}
+	switch constraint {
+	case "users_username_key":
+		return "Username is already exist", true
+	case "users_email_key":
+		return "Email is already exist", true
+	default:
+		return "", false
+	}
+}
+
 // GetUserByID godoc
 // @Summary      Get user by ID
 // @Description  Retrieve a user by their ID
 // @Tags         users
 // @Produce      json
 // @Param        id path string true "User ID"  // Используем string для ID
+// @Param        If-None-Match header string false "ETag from a previous response"
+// @Param        If-Modified-Since header string false "Last-Modified from a previous response"
 // @Success      200 {object} SuccessResponse{data=models.UserResponse}
+// @Success      304 "Not modified"
 // @Failure      400 {object} ErrorResponse "Invalid user ID format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
 // @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
 // @Failure      500 {object} ErrorResponse "Internal server error"
-// @Router       /{id} [get]
+// @Router       /user/{id} [get]
 func (h *Handler) GetUserByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -88,6 +118,58 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 	}
 
 	user, err := h.services.GetUserById(c.Request.Context(), id)
+	h.respondUser(c, user, err)
+}
+
+// GetUserByUsername godoc
+// @Summary      Get user by username
+// @Description  Retrieve a user by username, case-insensitive
+// @Tags         users
+// @Produce      json
+// @Param        username path string true "Username"
+// @Param        If-None-Match header string false "ETag from a previous response"
+// @Param        If-Modified-Since header string false "Last-Modified from a previous response"
+// @Success      200 {object} SuccessResponse{data=models.UserResponse}
+// @Success      304 "Not modified"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/by-username/{username} [get]
+func (h *Handler) GetUserByUsername(c *gin.Context) {
+	user, err := h.services.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	h.respondUser(c, user, err)
+}
+
+// GetUserByEmail godoc
+// @Summary      Get user by email
+// @Description  Retrieve a user by email, case-insensitive
+// @Tags         users
+// @Produce      json
+// @Param        email query string true "Email"
+// @Param        If-None-Match header string false "ETag from a previous response"
+// @Param        If-Modified-Since header string false "Last-Modified from a previous response"
+// @Success      200 {object} SuccessResponse{data=models.UserResponse}
+// @Success      304 "Not modified"
+// @Failure      400 {object} ErrorResponse "Email query parameter is required"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/by-email [get]
+func (h *Handler) GetUserByEmail(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		NewErrorResponse(c, http.StatusBadRequest, "Email query parameter is required", errors.New("missing email"))
+		return
+	}
+
+	user, err := h.services.GetUserByEmail(c.Request.Context(), email)
+	h.respondUser(c, user, err)
+}
+
+// respondUser отдает пользователя с заголовками для условных запросов
+func (h *Handler) respondUser(c *gin.Context, user models.User, err error) {
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
@@ -97,10 +179,17 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	// Клиент уже получил актуальную версию
+	if h.notModified(c, userETag(user), user.UpdatedAt) {
+		return
+	}
+
 	userResponse := models.UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		UserProfile: user.UserProfile,
+		Avatar:      h.services.AvatarURLs(user.Avatar),
 	}
 
 	// Формируем ответ
@@ -112,6 +201,55 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// Максимум идентификаторов в одном запросе POST /user/lookup
+const maxLookupIdentifiers = 100
+
+// LookupUsers godoc
+// @Summary      Look up users
+// @Description  Resolve usernames and emails to users in a single query, case-insensitive. Results are keyed by the requested identifier, identifiers that matched no user map to null
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        lookup body models.UserLookupInput true "Usernames and emails, up to 100 in total"
+// @Success      200 {object} SuccessResponse{data=models.UserLookupResult}
+// @Failure      400 {object} ErrorResponse "Invalid input format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      429 {object} ErrorResponse "Too many requests"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /user/lookup [post]
+func (h *Handler) LookupUsers(c *gin.Context) {
+	var input models.UserLookupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "Invalid input format", err)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, error_handler.ParseValidationErrors(err), err)
+		return
+	}
+	total := len(input.Usernames) + len(input.Emails)
+	if total == 0 {
+		NewErrorResponse(c, http.StatusBadRequest, "At least one username or email is required", errors.New("empty lookup"))
+		return
+	}
+	if total > maxLookupIdentifiers {
+		NewErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("At most %d usernames and emails per request", maxLookupIdentifiers), errors.New("too many identifiers"))
+		return
+	}
+
+	result, err := h.services.LookupUsers(c.Request.Context(), &input)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status: StatusSuccess,
+		Data:   result,
+	})
+}
+
 // UpdateUser godoc
 // @Summary      Update user
 // @Description  Update user details by ID
@@ -122,9 +260,12 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 // @Param        user body models.UserUpdate true "Updated User Data"
 // @Success      200 {object} SuccessResponse{data=string} "User updated successfully"
 // @Failure      400 {object} ErrorResponse "Invalid input format"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Another user, or username or email already exists"
 // @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
 // @Failure      500 {object} ErrorResponse "Internal server error"
-// @Router       /{id} [put]
+// @Router       /user/{id} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	var input models.UserUpdate
 
@@ -152,8 +293,13 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 
 	// Обновляем пользователя
 	if err := h.services.UpdateUser(c.Request.Context(), &input); err != nil {
-		if err.Error() == "user not found" {
+		var validationErrors models.ValidationErrors
+		if errors.Is(err, service.ErrUserNotFound) {
 			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+		} else if errors.As(err, &validationErrors) {
+			NewErrorResponse(c, http.StatusBadRequest, error_handler.ParseValidationErrors(err), err)
+		} else if message, ok := duplicateUserMessage(err); ok {
+			NewErrorResponse(c, http.StatusForbidden, message, err)
 		} else {
 			NewErrorResponse(c, http.StatusInternalServerError, "Failed to update user", err)
 		}
@@ -176,9 +322,12 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 // @Produce      json
 // @Param        id path string true "User ID"  // Используем string для ID
 // @Success      200 {object} SuccessResponse{data=string} "User deleted successfully"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      403 {object} ErrorResponse "Another user"
 // @Failure      404 {object} ErrorResponse "User not found"
+// @Failure      429 {object} ErrorResponse "Too many requests"
 // @Failure      500 {object} ErrorResponse "Internal server error"
-// @Router       /{id} [delete]
+// @Router       /user/{id} [delete]
 func (h *Handler) DeleteUser(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam) // Преобразуем строку в число
@@ -187,7 +336,11 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.DeleteUser(c, id); err != nil {
+	if err := h.services.DeleteUser(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			NewErrorResponse(c, http.StatusNotFound, "User not found", err)
+			return
+		}
 		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
@@ -205,11 +358,26 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 // @Description  Get a list of all users
 // @Tags         users
 // @Produce      json
+// @Param        If-None-Match header string false "ETag from a previous response"
+// @Param        If-Modified-Since header string false "Last-Modified from a previous response"
 // @Success      200 {object} SuccessResponse{data=[]models.UserResponse}
+// @Success      304 "Not modified"
+// @Failure      401 {object} ErrorResponse "Authentication required or invalid credentials"
+// @Failure      429 {object} ErrorResponse "Too many requests"
 // @Failure      500 {object} ErrorResponse "Internal server error"
-// @Router       / [get]
+// @Router       /user/ [get]
 func (h *Handler) ListUser(c *gin.Context) {
-	users, err := h.services.ListUser(c)
+	// Сначала проверяем версию списка, чтобы не выбирать его целиком, если клиент уже получил актуальный
+	version, err := h.services.GetUserListVersion(c.Request.Context())
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+	if h.notModified(c, userListETag(version), version.LastModified) {
+		return
+	}
+
+	users, err := h.services.ListUser(c.Request.Context())
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "Something went wrong", err)
 		return
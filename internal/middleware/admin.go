@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/configs"
+)
+
+// AdminAuth пропускает только запросы с административным токеном в заголовке Authorization: Bearer
+// или с проверенным клиентским сертификатом, CN которого разрешен в конфигурации.
+// Если не задан ни токен, ни сертификаты, административные маршруты недоступны.
+func AdminAuth(cfg *configs.AdminConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Token == "" && len(cfg.ClientCertSubjects) == 0 {
+			abortWithError(c, http.StatusForbidden, "Admin access is disabled")
+			return
+		}
+
+		if subject, ok := c.Get(ClientCertKey); ok && slices.Contains(cfg.ClientCertSubjects, subject.(string)) {
+			c.Next()
+			return
+		}
+
+		provided, ok := bearerToken(c)
+		if !ok || cfg.Token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.Token)) != 1 {
+			abortWithError(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/pkg/logging"
+)
+
+// TokenParser проверяет токен доступа и его сессию и возвращает данные из токена
+type TokenParser interface {
+	ParseToken(ctx context.Context, token string) (models.TokenClaims, error)
+}
+
+// APIKeyAuthenticator проверяет API-ключ сервиса
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (models.APIKey, error)
+}
+
+// Authenticate определяет пользователя по токену из заголовка Authorization: Bearer
+// или сервис по ключу из заголовка Authorization: ApiKey.
+// Запросы без учетных данных пропускаются анонимно. Недействительный, просроченный токен, токен отозванной
+// сессии и недействительный API-ключ отклоняются с кодом 401, сбой проверки - с кодом 500.
+// Не подходит для маршрутов, принимающих в Authorization другие токены, как AdminAuth.
+func Authenticate(parser TokenParser, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := bearerToken(c); ok {
+			claims, err := parser.ParseToken(c.Request.Context(), token)
+			if err != nil {
+				rejectCredentials(c, "Access token", "Invalid or expired access token", err)
+				return
+			}
+			c.Set(UserIDKey, claims.UserID)
+			c.Set(SessionIDKey, claims.SessionID)
+			c.Set(TenantIDKey, claims.TenantID)
+			c.Set(TokenScopeKey, claims.Scope)
+		} else if rawKey, ok := apiKeyHeader(c); ok {
+			key, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), rawKey)
+			if err != nil {
+				rejectCredentials(c, "API key", "Invalid API key", err)
+				return
+			}
+			c.Set(APIKeyIDKey, key.ID)
+			c.Set(APIKeyScopesKey, key.Scopes)
+			c.Set(TenantIDKey, key.TenantID)
+		}
+		c.Next()
+	}
+}
+
+// rejectCredentials прерывает запрос, учетные данные которого не удалось принять: недействительные
+// данные отклоняются с кодом 401, сбой их проверки - с кодом 500. Анонимно такой запрос не выполняется
+func rejectCredentials(c *gin.Context, kind, message string, err error) {
+	log := logging.FromContext(c.Request.Context())
+	if errors.Is(err, models.ErrUnauthenticated) {
+		log.Warnf("%s rejected: %v", kind, err)
+		abortWithError(c, http.StatusUnauthorized, message)
+		return
+	}
+
+	log.Errorf("%s check failed: %v", kind, err)
+	abortWithError(c, http.StatusInternalServerError, "Something went wrong")
+}
+
+// RequireCaller пропускает только запросы пользователя с токеном полного доступа или сервиса с API-ключом
+func RequireCaller() gin.HandlerFunc {
+	requireUser := RequireAuth()
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyIDKey); ok {
+			c.Next()
+			return
+		}
+		requireUser(c)
+	}
+}
+
+// RequireAuth пропускает только аутентифицированные запросы.
+// Токены с ограниченной областью действия допускаются, только если область перечислена в scopes.
+func RequireAuth(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(UserIDKey); !ok {
+			abortWithError(c, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		if scope := c.GetString(TokenScopeKey); scope != models.ScopeFull && !slices.Contains(scopes, scope) {
+			abortWithError(c, http.StatusForbidden, "Token scope does not permit this action")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSelfOrAPIKey пропускает пользователя только к его собственной записи (параметр :id),
+// сервис с API-ключом - к любому пользователю арендатора ключа. Область ключа проверяет RequireAPIKeyScope,
+// некорректный :id отклоняет обработчик
+func RequireSelfOrAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyIDKey); ok {
+			c.Next()
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err == nil && id != c.GetInt(UserIDKey) {
+			abortWithError(c, http.StatusForbidden, "You can only change your own account")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAPIKeyScope требует область scope у запросов с API-ключом.
+// Запросы пользователей и анонимные запросы не затрагиваются, анонимные отклоняет RequireCaller.
+func RequireAPIKeyScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyIDKey); ok && !slices.Contains(c.GetStringSlice(APIKeyScopesKey), scope) {
+			abortWithError(c, http.StatusForbidden, "API key does not have the required scope")
+			return
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+func apiKeyHeader(c *gin.Context) (string, bool) {
+	key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey ")
+	return key, ok && key != ""
+}
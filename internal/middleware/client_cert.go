@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCert сохраняет в контексте CN проверенного клиентского сертификата (mTLS),
+// чтобы внутренние сервисы могли аутентифицироваться сертификатом.
+func ClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subject, ok := verifiedClientSubject(c); ok {
+			c.Set(ClientCertKey, subject)
+		}
+		c.Next()
+	}
+}
+
+// verifiedClientSubject возвращает CN сертификата, прошедшего проверку по CA клиентов
+func verifiedClientSubject(c *gin.Context) (string, bool) {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName, true
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/db"
+)
+
+// DBRouting закрепляет чтения запроса за одной репликой базы данных
+// и позволяет переключить их на основную базу после записи (read_your_writes)
+func DBRouting() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(db.WithRouting(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/pkg/logging"
+)
+
+// Logger пишет в лог каждый обработанный запрос в виде структурированных полей.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		fields := logrus.Fields{
+			"method":    c.Request.Method,
+			"route":     c.FullPath(),
+			"path":      c.Request.URL.Path,
+			"status":    c.Writer.Status(),
+			"latency":   time.Since(start).String(),
+			"client_ip": c.ClientIP(),
+		}
+		if userID, ok := c.Get(UserIDKey); ok {
+			fields["user_id"] = userID
+		}
+		if apiKeyID, ok := c.Get(APIKeyIDKey); ok {
+			fields["api_key_id"] = apiKeyID
+		}
+		if subject, ok := c.Get(ClientCertKey); ok {
+			fields["client_cert"] = subject
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+
+		entry := logging.FromContext(c.Request.Context()).WithFields(fields)
+		switch status := c.Writer.Status(); {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request completed")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
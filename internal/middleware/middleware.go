@@ -1 +1,38 @@
 package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader - заголовок, в котором передается идентификатор запроса
+	RequestIDHeader = "X-Request-ID"
+	// TenantHeader - заголовок, в котором передается арендатор запросов без токена пользователя
+	TenantHeader = "X-Tenant-ID"
+
+	// Ключи gin.Context, которые заполняют middleware
+	RequestIDKey    = "requestID"
+	UserIDKey       = "userID"
+	SessionIDKey    = "sessionID"
+	TenantIDKey     = "tenantID"
+	TokenScopeKey   = "tokenScope"
+	APIKeyIDKey     = "apiKeyID"
+	APIKeyScopesKey = "apiKeyScopes"
+	ClientCertKey   = "clientCert"
+
+	statusError = "failed"
+)
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// abortWithError прерывает обработку запроса и отдает ошибку в формате handler.NewErrorResponse.
+func abortWithError(c *gin.Context, statusCode int, message string) {
+	c.AbortWithStatusJSON(statusCode, gin.H{
+		"status": statusError,
+		"error": errorResponse{
+			Message: message,
+		},
+	})
+}
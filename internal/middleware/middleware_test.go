@@ -0,0 +1,307 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/ratelimit"
+)
+
+func TestRequestID_Generated(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(RequestIDKey))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+	assert.Equal(t, w.Header().Get(RequestIDHeader), w.Body.String())
+}
+
+func TestRequestID_Propagated(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+}
+
+func TestRecovery_ReturnsErrorEnvelope(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID(), Logger(), Recovery())
+	r.GET("/", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var actualResponse map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &actualResponse)
+	assert.NoError(t, err)
+
+	expectedResponse := map[string]interface{}{
+		"status": "failed",
+		"error": map[string]interface{}{
+			"message": "Something went wrong",
+		},
+	}
+
+	assert.Equal(t, expectedResponse, actualResponse)
+}
+
+func TestRateLimiter_TooManyRequests(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), &configs.RateLimitConfig{
+		Enabled: true,
+		Rules: map[string]configs.RateLimitRule{
+			"users": {Requests: 1, Period: time.Minute, Burst: 2, Key: "ip"},
+		},
+	})
+
+	r := gin.New()
+	r.GET("/", limiter.Limit("users"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":"failed","error":{"message":"Too many requests"}}`, w.Body.String())
+}
+
+func TestRequireAuth_Scopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   interface{}
+		scope    string
+		expected int
+	}{
+		{name: "anonymous", expected: http.StatusUnauthorized},
+		{name: "full access", userID: 1, scope: models.ScopeFull, expected: http.StatusOK},
+		{name: "restricted scope", userID: 1, scope: "other", expected: http.StatusForbidden},
+		{name: "allowed scope", userID: 1, scope: models.ScopeTOTPEnroll, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.userID != nil {
+					c.Set(UserIDKey, tt.userID)
+					c.Set(TokenScopeKey, tt.scope)
+				}
+			})
+			r.GET("/", RequireAuth(models.ScopeTOTPEnroll), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+type fakeAPIKeys map[string]models.APIKey
+
+func (f fakeAPIKeys) AuthenticateAPIKey(_ context.Context, rawKey string) (models.APIKey, error) {
+	if rawKey == "ak_broken.secret" {
+		return models.APIKey{}, errors.New("connection refused")
+	}
+	key, ok := f[rawKey]
+	if !ok {
+		return models.APIKey{}, models.ErrUnauthenticated
+	}
+	return key, nil
+}
+
+func TestAuthenticate_APIKeyScopes(t *testing.T) {
+	apiKeys := fakeAPIKeys{
+		"ak_reader.secret": {ID: 1, Scopes: []string{models.APIKeyScopeUsersRead}},
+	}
+
+	r := gin.New()
+	r.Use(Authenticate(nil, apiKeys))
+	r.GET("/", RequireCaller(), RequireAPIKeyScope(models.APIKeyScopeUsersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.POST("/", RequireCaller(), RequireAPIKeyScope(models.APIKeyScopeUsersWrite), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		expected int
+	}{
+		{name: "read with scope", method: http.MethodGet, header: "ApiKey ak_reader.secret", expected: http.StatusOK},
+		{name: "write without scope", method: http.MethodPost, header: "ApiKey ak_reader.secret", expected: http.StatusForbidden},
+		{name: "invalid key", method: http.MethodPost, header: "ApiKey ak_unknown.secret", expected: http.StatusUnauthorized},
+		{name: "key check failure", method: http.MethodGet, header: "ApiKey ak_broken.secret", expected: http.StatusInternalServerError},
+		{name: "anonymous", method: http.MethodPost, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+type fakeTokens map[string]error
+
+func (f fakeTokens) ParseToken(_ context.Context, token string) (models.TokenClaims, error) {
+	err, ok := f[token]
+	if !ok {
+		return models.TokenClaims{}, models.ErrUnauthenticated
+	}
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+	return models.TokenClaims{UserID: 1, TenantID: 1}, nil
+}
+
+func TestAuthenticate_BearerToken(t *testing.T) {
+	tokens := fakeTokens{
+		"valid":   nil,
+		"revoked": fmt.Errorf("%w: session is revoked or expired", models.ErrUnauthenticated),
+		"db-down": errors.New("connection refused"),
+	}
+
+	r := gin.New()
+	r.Use(Authenticate(tokens, nil))
+	r.GET("/", func(c *gin.Context) {
+		_, ok := c.Get(UserIDKey)
+		c.String(http.StatusOK, strconv.FormatBool(ok))
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		expected int
+		body     string
+	}{
+		{name: "valid token", header: "Bearer valid", expected: http.StatusOK, body: "true"},
+		{name: "anonymous", expected: http.StatusOK, body: "false"},
+		{name: "invalid token", header: "Bearer forged", expected: http.StatusUnauthorized},
+		{name: "revoked session", header: "Bearer revoked", expected: http.StatusUnauthorized},
+		{name: "session lookup failure", header: "Bearer db-down", expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenTenant int
+		keyTenant   int
+		header      string
+		expected    int
+		tenantID    string
+	}{
+		{name: "anonymous default", expected: http.StatusOK, tenantID: "1"},
+		{name: "anonymous header", header: "7", expected: http.StatusOK, tenantID: "7"},
+		{name: "invalid header", header: "abc", expected: http.StatusBadRequest},
+		{name: "token tenant", tokenTenant: 3, expected: http.StatusOK, tenantID: "3"},
+		{name: "token tenant with same header", tokenTenant: 3, header: "3", expected: http.StatusOK, tenantID: "3"},
+		{name: "token tenant with other header", tokenTenant: 3, header: "4", expected: http.StatusForbidden},
+		{name: "api key tenant", keyTenant: 5, expected: http.StatusOK, tenantID: "5"},
+		{name: "api key tenant with same header", keyTenant: 5, header: "5", expected: http.StatusOK, tenantID: "5"},
+		{name: "api key tenant with other header", keyTenant: 5, header: "1", expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.tokenTenant != 0 {
+					c.Set(UserIDKey, 1)
+					c.Set(TenantIDKey, tt.tokenTenant)
+				}
+				if tt.keyTenant != 0 {
+					c.Set(APIKeyIDKey, 1)
+					c.Set(TenantIDKey, tt.keyTenant)
+				}
+			})
+			r.GET("/", ResolveTenant(), func(c *gin.Context) {
+				c.String(http.StatusOK, strconv.Itoa(tenant.FromContext(c.Request.Context())))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusOK {
+				assert.Equal(t, tt.tenantID, w.Body.String())
+			}
+		})
+	}
+}
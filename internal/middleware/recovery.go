@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/pkg/logging"
+)
+
+// Recovery перехватывает панику в обработчике и отдает клиенту 500 в стандартном формате ошибки.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logging.FromContext(c.Request.Context()).
+					WithField("panic", rec).
+					WithField("stack", string(debug.Stack())).
+					Error("Recovered from panic")
+
+				abortWithError(c, http.StatusInternalServerError, "Something went wrong")
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/pkg/logging"
+)
+
+// Максимальная длина идентификатора запроса, принимаемого от клиента
+const maxRequestIDLength = 128
+
+// RequestID назначает запросу идентификатор (или берет его из заголовка X-Request-ID)
+// и привязывает к контексту запроса логгер с этим идентификатором.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !ValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		// Логгер запроса доступен в сервисах и репозиториях через logging.FromContext
+		entry := logrus.WithField("request_id", requestID)
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), entry))
+
+		c.Next()
+	}
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID проверяет, что идентификатор от клиента безопасно писать в логи, заголовки и метаданные gRPC
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"simple_crud_go/internal/tenant"
+)
+
+// ResolveTenant определяет арендатора запроса и привязывает его к контексту запроса.
+// Для пользователя с токеном и сервиса с API-ключом арендатор берется из учетных данных,
+// заголовок X-Tenant-ID может только совпадать с ним. Анонимные запросы (вход и регистрация)
+// указывают арендатора заголовком, без него - арендатор по умолчанию.
+// Должен стоять после Authenticate.
+func ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(TenantHeader)
+
+		tenantID := tenant.DefaultID
+		_, isUser := c.Get(UserIDKey)
+		_, isAPIKey := c.Get(APIKeyIDKey)
+		if isUser || isAPIKey {
+			tenantID = c.GetInt(TenantIDKey)
+			if header != "" && header != strconv.Itoa(tenantID) {
+				message := "Tenant does not match the access token"
+				if isAPIKey {
+					message = "Tenant does not match the API key"
+				}
+				abortWithError(c, http.StatusForbidden, message)
+				return
+			}
+		} else if header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil || id <= 0 {
+				abortWithError(c, http.StatusBadRequest, "Invalid X-Tenant-ID header")
+				return
+			}
+			tenantID = id
+		}
+
+		c.Set(TenantIDKey, tenantID)
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
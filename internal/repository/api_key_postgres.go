@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"simple_crud_go/internal/db/models"
+)
+
+const apiKeyColumns = `id, tenant_id, name, prefix, secret_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+type apiKeyRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool, queryTimeout time.Duration) APIKeyRepository {
+	return &apiKeyRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var id int
+	query := `INSERT INTO api_keys (tenant_id, name, prefix, secret_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	row := r.db.QueryRow(ctx, query, key.TenantID, key.Name, key.Prefix, key.SecretHash, key.Scopes, key.ExpiresAt)
+	if err := row.Scan(&id, &key.CreatedAt); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	return scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) RotateAPIKey(ctx context.Context, id int, secretHash string) (models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE api_keys SET secret_hash = $1 WHERE id = $2 AND revoked_at IS NULL
+		RETURNING ` + apiKeyColumns
+	return scanAPIKey(r.db.QueryRow(ctx, query, secretHash, id))
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Обновляем не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	query := `UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func scanAPIKey(row pgx.Row) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.TenantID, &key.Name, &key.Prefix, &key.SecretHash, &key.Scopes,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
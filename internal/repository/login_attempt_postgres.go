@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type loginAttemptRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool, queryTimeout time.Duration) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *loginAttemptRepository) LockRemaining(ctx context.Context, scope, key string) (time.Duration, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var seconds float64
+	query := `SELECT EXTRACT(EPOCH FROM locked_until - now())::float8 FROM login_failures
+		WHERE scope = $1 AND key = $2 AND locked_until > now()`
+	if err := r.db.QueryRow(ctx, query, scope, key).Scan(&seconds); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, scope, key string, window time.Duration) (int, int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Неудачи старше окна не учитываются, счетчик начинается заново
+	var failures, lockouts int
+	query := `
+		INSERT INTO login_failures AS f (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, now())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN f.last_failure_at < now() - make_interval(secs => $3) THEN 1 ELSE f.failures + 1 END,
+			last_failure_at = now()
+		RETURNING failures, lockouts`
+	if err := r.db.QueryRow(ctx, query, scope, key, window.Seconds()).Scan(&failures, &lockouts); err != nil {
+		return 0, 0, err
+	}
+
+	return failures, lockouts, nil
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, scope, key string, duration time.Duration) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE login_failures
+		SET failures = 0, lockouts = lockouts + 1, locked_until = now() + make_interval(secs => $3)
+		WHERE scope = $1 AND key = $2`
+	_, err := r.db.Exec(ctx, query, scope, key, duration.Seconds())
+	return err
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, scope, key string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM login_failures WHERE scope = $1 AND key = $2`
+	_, err := r.db.Exec(ctx, query, scope, key)
+	return err
+}
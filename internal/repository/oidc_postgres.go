@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/tenant"
+)
+
+type oidcRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func NewOIDCRepository(db *pgxpool.Pool, queryTimeout time.Duration) OIDCRepository {
+	return &oidcRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *oidcRepository) SaveOIDCState(ctx context.Context, state *models.OIDCState) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Пользователи, не вернувшиеся от провайдера, оставляют состояния - удаляем их при создании новых
+	if _, err := r.db.Exec(ctx, `DELETE FROM oidc_states WHERE expires_at < now()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_states (state, provider, nonce, code_verifier, link_user_id, tenant_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(ctx, query, state.State, state.Provider, state.Nonce, state.CodeVerifier, state.LinkUserID, state.TenantID, state.ExpiresAt)
+	return err
+}
+
+func (r *oidcRepository) ConsumeOIDCState(ctx context.Context, state string) (models.OIDCState, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var s models.OIDCState
+	query := `DELETE FROM oidc_states WHERE state = $1
+		RETURNING state, provider, nonce, code_verifier, link_user_id, tenant_id, expires_at`
+	row := r.db.QueryRow(ctx, query, state)
+	if err := row.Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.LinkUserID, &s.TenantID, &s.ExpiresAt); err != nil {
+		return models.OIDCState{}, err
+	}
+	return s, nil
+}
+
+func (r *oidcRepository) GetUserByIdentity(ctx context.Context, provider, subject string) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var user models.User
+	query := `SELECT u.id, u.tenant_id, u.username, COALESCE(u.email, ''), u.role, u.totp_enabled
+		FROM user_identities i JOIN users u ON u.id = i.user_id
+		WHERE i.tenant_id = $1 AND i.provider = $2 AND i.subject = $3`
+	row := r.db.QueryRow(ctx, query, tenant.FromContext(ctx), provider, subject)
+	if err := row.Scan(&user.ID, &user.TenantID, &user.Username, &user.Email, &user.Role, &user.TOTPEnabled); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (r *oidcRepository) LinkIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return insertIdentity(ctx, r.db, identity)
+}
+
+func (r *oidcRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var id int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// Email без подтверждения провайдером не сохраняем, пустой email хранится как NULL
+		query := `INSERT INTO users (tenant_id, username, email, password) VALUES ($1, $2, NULLIF($3, ''), $4) RETURNING id`
+		if err := tx.QueryRow(ctx, query, tenant.FromContext(ctx), user.Username, user.Email, user.Password).Scan(&id); err != nil {
+			return err
+		}
+
+		identity.UserID = id
+		return insertIdentity(ctx, tx, identity)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// execer - общий интерфейс пула и транзакции
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// insertIdentity привязывает внешний аккаунт в арендаторе из контекста
+func insertIdentity(ctx context.Context, db execer, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (tenant_id, user_id, provider, subject, email) VALUES ($1, $2, $3, $4, NULLIF($5, ''))`
+	_, err := db.Exec(ctx, query, tenant.FromContext(ctx), identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	return err
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/tenant"
+)
+
+func TestOIDCRepository_IdentityPerTenant(t *testing.T) {
+	cluster := newTestCluster(t)
+	repo := NewOIDCRepository(cluster.Primary(), time.Minute)
+	ctx := context.Background()
+
+	_, err := cluster.Primary().Exec(ctx, `INSERT INTO tenants (id, name) VALUES (2, 'other')`)
+	require.NoError(t, err)
+
+	// Один и тот же внешний аккаунт создает по пользователю в каждом арендаторе
+	ids := map[int]int{}
+	for _, tenantID := range []int{tenant.DefaultID, 2} {
+		tenantCtx := tenant.WithID(ctx, tenantID)
+		id, err := repo.CreateUserWithIdentity(tenantCtx, &models.User{Username: "alice", Password: "hash"},
+			&models.UserIdentity{Provider: "google", Subject: "123"})
+		require.NoError(t, err)
+		ids[tenantID] = id
+	}
+
+	for tenantID, id := range ids {
+		user, err := repo.GetUserByIdentity(tenant.WithID(ctx, tenantID), "google", "123")
+		require.NoError(t, err)
+		assert.Equal(t, id, user.ID)
+		assert.Equal(t, tenantID, user.TenantID)
+	}
+}
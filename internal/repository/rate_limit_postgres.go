@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"simple_crud_go/pkg/ratelimit"
+)
+
+type rateLimitRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+// NewRateLimitRepository создает хранилище ведер токенов в PostgreSQL,
+// чтобы несколько реплик приложения разделяли одни и те же лимиты.
+func NewRateLimitRepository(db *pgxpool.Pool, queryTimeout time.Duration) ratelimit.Store {
+	return &rateLimitRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Take пополняет ведро и забирает токен одним запросом, время берется из часов базы
+func (r *rateLimitRepository) Take(ctx context.Context, key string, rule ratelimit.Rule) (ratelimit.Result, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8)
+				- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) >= 1 THEN 1 ELSE 0 END,
+			allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) >= 1,
+			updated_at = now()
+		RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+	if err := r.db.QueryRow(ctx, query, key, rule.Burst, rule.Rate).Scan(&tokens, &allowed); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return ratelimit.NewResult(rule, tokens, allowed), nil
+}
+
+func (r *rateLimitRepository) Cleanup(ctx context.Context, idle time.Duration) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)`
+	_, err := r.db.Exec(ctx, query, idle.Seconds())
+	return err
+}
@@ -2,24 +2,120 @@ package repository
 
 import (
 	"context"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-
+	"simple_crud_go/internal/db"
 	"simple_crud_go/internal/db/models"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) (int, error)
 	GetUserById(ctx context.Context, id int) (models.User, error)
+	// FindUserByUsername и FindUserByEmail ищут без учета регистра по нормализованному значению.
+	// В отличие от GetUserByUsername данные для входа не выбираются, чтение идет с реплики
+	FindUserByUsername(ctx context.Context, username string) (models.User, error)
+	FindUserByEmail(ctx context.Context, email string) (models.User, error)
+	// LookupUsers ищет пользователей по именам и email одним запросом. Результат содержит каждый
+	// переданный идентификатор, для ненайденных - nil
+	LookupUsers(ctx context.Context, usernames, emails []string) (models.UserLookupResult, error)
+	// GetUserByUsername ищет пользователя без учета регистра, имя передается уже нормализованным
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	// UpdateUser оставляет текущие значения для пустых имени и email и незаданных полей профиля
 	UpdateUser(ctx context.Context, user *models.UserUpdate) error
-	DeleteUser(ctx context.Context, id int) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// SetUserAvatar заменяет аватар пользователя и возвращает прежний (nil - аватара не было)
+	SetUserAvatar(ctx context.Context, id int, avatar *models.AvatarObjects) (*models.AvatarObjects, error)
+	// DeleteUser удаляет пользователя и возвращает его аватар (nil - аватара не было)
+	DeleteUser(ctx context.Context, id int) (*models.AvatarObjects, error)
 	ListUser(ctx context.Context) ([]models.UserResponse, error)
+	ListUserPage(ctx context.Context, filter models.UserFilter, afterID, limit int) ([]models.UserResponse, error)
+	GetUsersByIDs(ctx context.Context, ids []int) ([]models.UserResponse, error)
+	GetUserListVersion(ctx context.Context) (models.UserListVersion, error)
+}
+
+// LoginAttemptRepository хранит счетчики неудачных входов по аккаунтам и IP
+type LoginAttemptRepository interface {
+	// LockRemaining возвращает оставшееся время блокировки, 0 - блокировки нет
+	LockRemaining(ctx context.Context, scope, key string) (time.Duration, error)
+	// RegisterFailure учитывает неудачную попытку и возвращает число неудач в окне и число прошлых блокировок
+	RegisterFailure(ctx context.Context, scope, key string, window time.Duration) (failures int, lockouts int, err error)
+	// Lock блокирует вход на duration и сбрасывает счетчик неудач
+	Lock(ctx context.Context, scope, key string, duration time.Duration) error
+	// Reset снимает блокировку и обнуляет счетчики
+	Reset(ctx context.Context, scope, key string) error
+}
+
+// TOTPRepository хранит секреты TOTP и коды восстановления
+type TOTPRepository interface {
+	GetTOTP(ctx context.Context, userID int) (models.TOTPState, error)
+	// SetTOTPSecret сохраняет новый неподтвержденный секрет, если TOTP еще не включен
+	SetTOTPSecret(ctx context.Context, userID int, secret string) (bool, error)
+	// EnableTOTP включает TOTP и заменяет коды восстановления
+	EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep отмечает шаг времени использованным, false - код этого шага уже использовался
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	// UseRecoveryCode гасит код восстановления, false - код не найден или уже использован
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
+
+// APIKeyRepository хранит API-ключи
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (int, error)
+	// GetAPIKeyByPrefix возвращает ключ вместе с хэшем секрета
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RotateAPIKey заменяет секрет действующего ключа
+	RotateAPIKey(ctx context.Context, id int, secretHash string) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// TouchAPIKey обновляет время последнего использования
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+// OIDCRepository хранит состояния входа через OIDC и привязки внешних аккаунтов
+type OIDCRepository interface {
+	// SaveOIDCState сохраняет состояние входа и удаляет просроченные
+	SaveOIDCState(ctx context.Context, state *models.OIDCState) error
+	// ConsumeOIDCState возвращает и удаляет состояние, повторно его использовать нельзя
+	ConsumeOIDCState(ctx context.Context, state string) (models.OIDCState, error)
+	GetUserByIdentity(ctx context.Context, provider, subject string) (models.User, error)
+	LinkIdentity(ctx context.Context, identity *models.UserIdentity) error
+	// CreateUserWithIdentity создает пользователя вместе с привязкой внешнего аккаунта
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) (int, error)
+}
+
+// SessionRepository хранит сессии пользователей
+type SessionRepository interface {
+	// CreateSession сохраняет сессию и удаляет истекшие сессии пользователя
+	CreateSession(ctx context.Context, session *models.Session) error
+	// TouchSession проверяет, что сессия действует, и обновляет время последней активности
+	TouchSession(ctx context.Context, id string, userID int) (bool, error)
+	// ListSessions возвращает действующие сессии пользователя
+	ListSessions(ctx context.Context, userID int) ([]models.Session, error)
+	RevokeSession(ctx context.Context, id string, userID int) error
+	// RevokeUserSessions отзывает все сессии пользователя и возвращает их число
+	RevokeUserSessions(ctx context.Context, userID int) (int, error)
 }
 
 type userRepository struct {
-	db *pgxpool.Pool
+	cluster      *db.Cluster
+	queryTimeout time.Duration
+}
+
+// NewUserRepository создает репозиторий пользователей. Все запросы ограничены арендатором из контекста,
+// запись идет в основную базу,
+// GetUserById и списки пользователей читаются с реплик.
+// queryTimeout ограничивает запросы, у контекста которых нет своего дедлайна (0 - без ограничения).
+func NewUserRepository(cluster *db.Cluster, queryTimeout time.Duration) UserRepository {
+	return &userRepository{cluster: cluster, queryTimeout: queryTimeout}
 }
 
-func NewUserRepository(db *pgxpool.Pool) UserRepository {
-	return &userRepository{db: db}
+// withQueryTimeout ограничивает время выполнения запроса, если у контекста нет своего дедлайна
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"simple_crud_go/internal/db/models"
+)
+
+type sessionRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func NewSessionRepository(db *pgxpool.Pool, queryTimeout time.Duration) SessionRepository {
+	return &sessionRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM sessions WHERE user_id = $1 AND expires_at < now()`, session.UserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO sessions (id, user_id, ip, user_agent, expires_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, last_seen_at`
+	row := r.db.QueryRow(ctx, query, session.ID, session.UserID, session.IP, session.UserAgent, session.ExpiresAt)
+	return row.Scan(&session.CreatedAt, &session.LastSeenAt)
+}
+
+func (r *sessionRepository) TouchSession(ctx context.Context, id string, userID int) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var lastSeen time.Time
+	query := `SELECT last_seen_at FROM sessions
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > now()`
+	if err := r.db.QueryRow(ctx, query, id, userID).Scan(&lastSeen); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Время активности обновляем не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	if time.Since(lastSeen) > time.Minute {
+		if _, err := r.db.Exec(ctx, `UPDATE sessions SET last_seen_at = now() WHERE id = $1`, id); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (r *sessionRepository) ListSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT id, user_id, ip, user_agent, created_at, last_seen_at, expires_at FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_seen_at DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, id string, userID int) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > now()`
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeUserSessions(ctx context.Context, userID int) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()`
+	tag, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"simple_crud_go/internal/db/models"
+)
+
+type totpRepository struct {
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func NewTOTPRepository(db *pgxpool.Pool, queryTimeout time.Duration) TOTPRepository {
+	return &totpRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *totpRepository) GetTOTP(ctx context.Context, userID int) (models.TOTPState, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var (
+		state    models.TOTPState
+		secret   *string
+		lastStep *int64
+	)
+	query := `SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1`
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&secret, &state.Enabled, &lastStep); err != nil {
+		return models.TOTPState{}, err
+	}
+	if secret != nil {
+		state.Secret = *secret
+	}
+	if lastStep != nil {
+		state.LastStep = *lastStep
+	}
+	return state, nil
+}
+
+func (r *totpRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2 AND NOT totp_enabled`
+	tag, err := r.db.Exec(ctx, query, secret, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *totpRepository) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `UPDATE users SET totp_enabled = true, totp_last_step = $1 WHERE id = $2`
+		if _, err := tx.Exec(ctx, query, step, userID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+
+		rows := make([][]interface{}, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			rows = append(rows, []interface{}{userID, hash})
+		}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"recovery_codes"}, []string{"user_id", "code_hash"}, pgx.CopyFromRows(rows))
+		return err
+	})
+}
+
+func (r *totpRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Код принимается только для шага новее последнего использованного, это исключает повтор
+	query := `UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`
+	tag, err := r.db.Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *totpRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
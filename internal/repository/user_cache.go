@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+
+	"simple_crud_go/internal/db"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/cache"
+	"simple_crud_go/pkg/logging"
+)
+
+var (
+	userCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "user_cache_hits_total",
+		Help: "Number of GetUserById calls served from the cache.",
+	})
+	userCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "user_cache_misses_total",
+		Help: "Number of GetUserById calls that went to the database.",
+	})
+)
+
+// cachedUser - данные пользователя в кэше; хэш пароля и служебные поля в кэш не попадают
+type cachedUser struct {
+	ID        int       `json:"id"`
+	TenantID  int       `json:"tenant_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Profile models.UserProfile    `json:"profile"`
+	Avatar  *models.AvatarObjects `json:"avatar,omitempty"`
+}
+
+// cachedUserRepository кэширует GetUserById и сбрасывает запись при изменении и удалении пользователя,
+// остальные методы передаются репозиторию без изменений
+type cachedUserRepository struct {
+	UserRepository
+	store cache.Store
+	ttl   time.Duration
+	group singleflight.Group
+
+	// Заполнения кэша, которые выполняются сейчас, по ключу
+	mu    sync.Mutex
+	fills map[string]*cacheFill
+}
+
+// cacheFill - заполнения записи кэша, начатые до ее очередного сброса
+type cacheFill struct {
+	active     int
+	generation uint64 // увеличивается при каждом сбросе записи во время заполнения
+}
+
+// NewCachedUserRepository оборачивает репозиторий кэшем с временем жизни записей ttl.
+// Одновременные промахи по одному ID выполняют один запрос к базе
+func NewCachedUserRepository(next UserRepository, store cache.Store, ttl time.Duration) UserRepository {
+	return &cachedUserRepository{UserRepository: next, store: store, ttl: ttl, fills: map[string]*cacheFill{}}
+}
+
+func (r *cachedUserRepository) GetUserById(ctx context.Context, id int) (models.User, error) {
+	key := userCacheKey(ctx, id)
+
+	raw, ok, err := r.store.Get(ctx, key)
+	if err != nil {
+		// Недоступность кэша не должна останавливать сервис
+		logging.FromContext(ctx).Warnf("User cache read failed: %v", err)
+	}
+	if ok {
+		var cached cachedUser
+		if err := json.Unmarshal(raw, &cached); err == nil {
+			userCacheHits.Inc()
+			return models.User{
+				ID: cached.ID, TenantID: cached.TenantID, Username: cached.Username, Email: cached.Email,
+				UserProfile: cached.Profile, UpdatedAt: cached.UpdatedAt, Avatar: cached.Avatar,
+			}, nil
+		}
+	}
+	userCacheMisses.Inc()
+
+	// Запрос выполняется вне отмены первого вызвавшего, чтобы его отмена не затронула остальных.
+	// Кэш заполняется из основной базы: отстающая реплика вернула бы в кэш данные до изменения
+	shared := db.WithPrimary(context.WithoutCancel(ctx))
+	result, err, _ := r.group.Do(key, func() (interface{}, error) {
+		generation := r.beginFill(key)
+		user, err := r.UserRepository.GetUserById(shared, id)
+		if err != nil {
+			r.endFill(key, generation)
+			return models.User{}, err
+		}
+
+		raw, _ := json.Marshal(cachedUser{
+			ID: user.ID, TenantID: user.TenantID, Username: user.Username, Email: user.Email,
+			UpdatedAt: user.UpdatedAt, Profile: user.UserProfile, Avatar: user.Avatar,
+		})
+		if err := r.store.Set(shared, key, raw, r.ttl); err != nil {
+			logging.FromContext(ctx).Warnf("User cache write failed: %v", err)
+		}
+
+		// Запись сброшена, пока пользователь читался из базы: записанные данные могли устареть
+		if !r.endFill(key, generation) {
+			if err := r.store.Delete(shared, key); err != nil {
+				logging.FromContext(ctx).Errorf("User cache invalidation failed for user %d: %v", id, err)
+			}
+		}
+		return user, nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return result.(models.User), nil
+}
+
+// UpdateUser и DeleteUser сбрасывают запись и тогда, когда пользователь не найден:
+// его могли удалить, а запись кэша осталась
+func (r *cachedUserRepository) UpdateUser(ctx context.Context, user *models.UserUpdate) error {
+	if err := r.UserRepository.UpdateUser(ctx, user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.invalidate(ctx, user.ID)
+		}
+		return err
+	}
+	r.invalidate(ctx, user.ID)
+	return nil
+}
+
+func (r *cachedUserRepository) SetUserAvatar(ctx context.Context, id int, avatar *models.AvatarObjects) (*models.AvatarObjects, error) {
+	previous, err := r.UserRepository.SetUserAvatar(ctx, id, avatar)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, id)
+	return previous, nil
+}
+
+func (r *cachedUserRepository) DeleteUser(ctx context.Context, id int) (*models.AvatarObjects, error) {
+	avatar, err := r.UserRepository.DeleteUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.invalidate(ctx, id)
+		}
+		return nil, err
+	}
+	r.invalidate(ctx, id)
+	return avatar, nil
+}
+
+// invalidate удаляет запись из кэша, новые промахи не присоединяются к запросу, начатому до изменения,
+// а заполнения, начатые до изменения, удаляют записанное ими. Изменения, выполненные другими репликами
+// с общим кэшем, здесь не видны: устаревшая запись от их заполнения живет не дольше ttl
+func (r *cachedUserRepository) invalidate(ctx context.Context, id int) {
+	key := userCacheKey(ctx, id)
+	r.group.Forget(key)
+
+	r.mu.Lock()
+	if fill, ok := r.fills[key]; ok {
+		fill.generation++
+	}
+	r.mu.Unlock()
+
+	if err := r.store.Delete(ctx, key); err != nil {
+		logging.FromContext(ctx).Errorf("User cache invalidation failed for user %d: %v", id, err)
+	}
+}
+
+// beginFill отмечает начало заполнения записи key и возвращает текущее поколение записи
+func (r *cachedUserRepository) beginFill(key string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fill, ok := r.fills[key]
+	if !ok {
+		fill = &cacheFill{}
+		r.fills[key] = fill
+	}
+	fill.active++
+	return fill.generation
+}
+
+// endFill завершает заполнение и сообщает, что запись не сбрасывалась с его начала
+func (r *cachedUserRepository) endFill(key string, generation uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fill := r.fills[key]
+	fill.active--
+	if fill.active == 0 {
+		delete(r.fills, key)
+	}
+	return fill.generation == generation
+}
+
+// Ключ включает арендатора, чтобы запись одного арендатора не была видна другому
+func userCacheKey(ctx context.Context, id int) string {
+	return "user:" + strconv.Itoa(tenant.FromContext(ctx)) + ":" + strconv.Itoa(id)
+}
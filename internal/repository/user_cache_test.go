@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/pkg/cache"
+)
+
+// countingUserRepository считает обращения к базе и задерживает чтение, чтобы промахи пересеклись
+type countingUserRepository struct {
+	UserRepository
+	reads    atomic.Int32
+	username string
+}
+
+func (r *countingUserRepository) GetUserById(_ context.Context, id int) (models.User, error) {
+	r.reads.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	return models.User{ID: id, Username: r.username, Password: "hash"}, nil
+}
+
+func (r *countingUserRepository) UpdateUser(_ context.Context, user *models.UserUpdate) error {
+	r.username = user.Username
+	return nil
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	next := &countingUserRepository{username: "before"}
+	repo := NewCachedUserRepository(next, cache.NewMemoryStore(10), time.Minute)
+	ctx := context.Background()
+
+	// Одновременные промахи по одному ID выполняют один запрос
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := repo.GetUserById(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "before", user.Username)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), next.reads.Load())
+
+	// Повторное чтение берется из кэша без хэша пароля
+	user, err := repo.GetUserById(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, user.Password)
+	assert.Equal(t, int32(1), next.reads.Load())
+
+	// Изменение сбрасывает запись
+	assert.NoError(t, repo.UpdateUser(ctx, &models.UserUpdate{ID: 1, Username: "after"}))
+	user, err = repo.GetUserById(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "after", user.Username)
+	assert.Equal(t, int32(2), next.reads.Load())
+}
+
+// blockingUserRepository останавливает чтение после получения данных из базы до сигнала release
+type blockingUserRepository struct {
+	UserRepository
+	mu       sync.Mutex
+	username string
+	read     chan struct{}
+	release  chan struct{}
+}
+
+func (r *blockingUserRepository) GetUserById(_ context.Context, id int) (models.User, error) {
+	r.mu.Lock()
+	username := r.username
+	r.mu.Unlock()
+
+	if r.read != nil {
+		close(r.read)
+		r.read = nil
+		<-r.release
+	}
+	return models.User{ID: id, Username: username}, nil
+}
+
+func (r *blockingUserRepository) UpdateUser(_ context.Context, user *models.UserUpdate) error {
+	r.mu.Lock()
+	r.username = user.Username
+	r.mu.Unlock()
+	return nil
+}
+
+func TestCachedUserRepository_WriteDuringFill(t *testing.T) {
+	next := &blockingUserRepository{username: "before", read: make(chan struct{}), release: make(chan struct{})}
+	repo := NewCachedUserRepository(next, cache.NewMemoryStore(10), time.Minute)
+	ctx := context.Background()
+
+	// Заполнение прочитало старые данные и ждет, пока их изменят
+	read := next.read
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		user, err := repo.GetUserById(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "before", user.Username)
+	}()
+	<-read
+
+	assert.NoError(t, repo.UpdateUser(ctx, &models.UserUpdate{ID: 1, Username: "after"}))
+	close(next.release)
+	<-done
+
+	// Устаревшие данные заполнения не остаются в кэше
+	user, err := repo.GetUserById(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "after", user.Username)
+}
@@ -3,13 +3,25 @@ package repository
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db"
 	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/tenant"
 )
 
 func (r *userRepository) CreateUser(ctx context.Context, user *models.User) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	defer db.MarkWritten(ctx)
+
 	var id int
-	query := `INSERT INTO users (username, email, password) VALUES ($1, $2, $3) RETURNING id`
-	row := r.db.QueryRow(ctx, query, user.Username, user.Email, user.Password)
+	query := `INSERT INTO users (tenant_id, username, email, password,
+			display_name, first_name, last_name, phone, locale, timezone, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, '{}'::jsonb)) RETURNING id`
+	p := user.UserProfile
+	row := r.cluster.Primary().QueryRow(ctx, query, tenant.FromContext(ctx), user.Username, user.Email, user.Password,
+		p.DisplayName, p.FirstName, p.LastName, p.Phone, p.Locale, p.Timezone, metadataArg(p.Metadata))
 	if err := row.Scan(&id); err != nil {
 		return 0, err
 	}
@@ -18,43 +30,248 @@ func (r *userRepository) CreateUser(ctx context.Context, user *models.User) (int
 }
 
 func (r *userRepository) GetUserById(ctx context.Context, id int) (models.User, error) {
+	return r.getUser(ctx, `id = $2`, id)
+}
+
+func (r *userRepository) FindUserByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.getUser(ctx, `lower(username) = lower($2)`, username)
+}
+
+func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.getUser(ctx, `lower(email) = lower($2)`, email)
+}
+
+// getUser выбирает пользователя арендатора по условию с параметром $2, данные для входа не выбираются
+func (r *userRepository) getUser(ctx context.Context, condition string, arg any) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	var user models.User
-	query := `SELECT id, username, email FROM users WHERE id = $1`
-	row := r.db.QueryRow(ctx, query, id)
-	if err := row.Scan(&user.ID, &user.Username, &user.Email); err != nil {
+	query := `SELECT id, tenant_id, username, COALESCE(email, ''), updated_at, ` + profileColumns + `
+		FROM users WHERE tenant_id = $1 AND ` + condition
+	row := r.cluster.Reader(ctx).QueryRow(ctx, query, tenant.FromContext(ctx), arg)
+	dest := append([]any{&user.ID, &user.TenantID, &user.Username, &user.Email, &user.UpdatedAt}, profileDest(&user.UserProfile, &user.Avatar)...)
+	if err := row.Scan(dest...); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var user models.User
+	query := `SELECT id, tenant_id, username, COALESCE(email, ''), password, role, totp_enabled
+		FROM users WHERE tenant_id = $1 AND lower(username) = lower($2)`
+	row := r.cluster.Primary().QueryRow(ctx, query, tenant.FromContext(ctx), username)
+	if err := row.Scan(&user.ID, &user.TenantID, &user.Username, &user.Email, &user.Password, &user.Role, &user.TOTPEnabled); err != nil {
 		return models.User{}, err
 	}
 	return user, nil
 }
 
 func (r *userRepository) UpdateUser(ctx context.Context, user *models.UserUpdate) error {
-	query := `UPDATE users SET username = $1, email = $2 WHERE id = $3`
-	_, err := r.db.Exec(ctx, query, user.Username, user.Email, user.ID)
-	return err
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	defer db.MarkWritten(ctx)
+
+	// Пустые имя и email и незаданные поля профиля (NULL) сохраняют текущие значения
+	query := `UPDATE users SET username = COALESCE(NULLIF($1, ''), username), email = COALESCE(NULLIF($2, ''), email),
+			display_name = COALESCE($3, display_name), first_name = COALESCE($4, first_name),
+			last_name = COALESCE($5, last_name), phone = COALESCE($6, phone),
+			locale = COALESCE($7, locale), timezone = COALESCE($8, timezone),
+			metadata = COALESCE($9, metadata), updated_at = now()
+		WHERE id = $10 AND tenant_id = $11`
+	tag, err := r.cluster.Primary().Exec(ctx, query, user.Username, user.Email,
+		user.DisplayName, user.FirstName, user.LastName, user.Phone, user.Locale, user.Timezone, metadataArg(user.Metadata),
+		user.ID, tenant.FromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
 
-func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+func (r *userRepository) SetUserAvatar(ctx context.Context, id int, avatar *models.AvatarObjects) (*models.AvatarObjects, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	defer db.MarkWritten(ctx)
+
+	// Прежний аватар возвращается для удаления его файлов
+	var previous *models.AvatarObjects
+	query := `UPDATE users u SET avatar = $1, updated_at = now()
+		FROM (SELECT id, avatar FROM users WHERE id = $2 AND tenant_id = $3 FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.avatar`
+	if err := r.cluster.Primary().QueryRow(ctx, query, avatar, id, tenant.FromContext(ctx)).Scan(&previous); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	defer db.MarkWritten(ctx)
+
+	query := `UPDATE users SET password = $1 WHERE id = $2 AND tenant_id = $3`
+	_, err := r.cluster.Primary().Exec(ctx, query, passwordHash, id, tenant.FromContext(ctx))
 	return err
 }
 
+func (r *userRepository) DeleteUser(ctx context.Context, id int) (*models.AvatarObjects, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+	defer db.MarkWritten(ctx)
+
+	var avatar *models.AvatarObjects
+	query := `DELETE FROM users WHERE id = $1 AND tenant_id = $2 RETURNING avatar`
+	if err := r.cluster.Primary().QueryRow(ctx, query, id, tenant.FromContext(ctx)).Scan(&avatar); err != nil {
+		return nil, err
+	}
+	return avatar, nil
+}
+
 func (r *userRepository) ListUser(ctx context.Context) ([]models.UserResponse, error) {
-	query := `SELECT id, username, email FROM users`
-	rows, err := r.db.Query(ctx, query)
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT id, username, COALESCE(email, ''), ` + profileColumns + ` FROM users WHERE tenant_id = $1`
+	rows, err := r.cluster.Reader(ctx).Query(ctx, query, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return scanUserResponses(rows)
+}
+
+func (r *userRepository) ListUserPage(ctx context.Context, filter models.UserFilter, afterID, limit int) ([]models.UserResponse, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Постраничная выборка по ключу: стабильна при вставках и не замедляется на дальних страницах
+	query := `SELECT id, username, COALESCE(email, ''), ` + profileColumns + ` FROM users
+		WHERE tenant_id = $1 AND id > $2
+			AND ($3::text = '' OR strpos(lower(username), lower($3)) > 0)
+			AND ($4::text = '' OR strpos(lower(COALESCE(email, '')), lower($4)) > 0)
+		ORDER BY id LIMIT $5`
+	rows, err := r.cluster.Reader(ctx).Query(ctx, query, tenant.FromContext(ctx), afterID, filter.Username, filter.Email, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanUserResponses(rows)
+}
+
+func (r *userRepository) GetUsersByIDs(ctx context.Context, ids []int) ([]models.UserResponse, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	query := `SELECT id, username, COALESCE(email, ''), ` + profileColumns + `
+		FROM users WHERE tenant_id = $1 AND id = ANY($2)`
+	rows, err := r.cluster.Reader(ctx).Query(ctx, query, tenant.FromContext(ctx), ids)
 	if err != nil {
 		return nil, err
 	}
+	return scanUserResponses(rows)
+}
+
+func (r *userRepository) LookupUsers(ctx context.Context, usernames, emails []string) (models.UserLookupResult, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Каждый запрошенный идентификатор дает строку, для ненайденных id - NULL.
+	// Имена и email ищутся отдельными соединениями, чтобы использовались индексы по lower(...)
+	columns := `q.value, u.id, COALESCE(u.username, ''), COALESCE(u.email, ''), ` + lookupProfileColumns
+	query := `SELECT 'username', ` + columns + ` FROM unnest($2::text[]) AS q(value)
+			LEFT JOIN users u ON u.tenant_id = $1 AND lower(u.username) = lower(q.value)
+		UNION ALL
+		SELECT 'email', ` + columns + ` FROM unnest($3::text[]) AS q(value)
+			LEFT JOIN users u ON u.tenant_id = $1 AND lower(u.email) = lower(q.value)`
+	rows, err := r.cluster.Reader(ctx).Query(ctx, query, tenant.FromContext(ctx), usernames, emails)
+	if err != nil {
+		return models.UserLookupResult{}, err
+	}
+	defer rows.Close()
+
+	result := models.UserLookupResult{
+		Usernames: make(map[string]*models.UserResponse, len(usernames)),
+		Emails:    make(map[string]*models.UserResponse, len(emails)),
+	}
+	for rows.Next() {
+		var (
+			kind, value string
+			id          *int
+			user        models.UserResponse
+		)
+		dest := append([]any{&kind, &value, &id, &user.Username, &user.Email}, profileDest(&user.UserProfile, &user.AvatarObjects)...)
+		if err := rows.Scan(dest...); err != nil {
+			return models.UserLookupResult{}, err
+		}
+
+		found := result.Usernames
+		if kind == "email" {
+			found = result.Emails
+		}
+		if id == nil {
+			found[value] = nil
+			continue
+		}
+		user.ID = *id
+		found[value] = &user
+	}
+
+	return result, rows.Err()
+}
+
+func (r *userRepository) GetUserListVersion(ctx context.Context) (models.UserListVersion, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// Добавление и изменение сдвигают max(updated_at), удаление уменьшает count
+	var version models.UserListVersion
+	query := `SELECT count(*), COALESCE(max(updated_at), 'epoch') FROM users WHERE tenant_id = $1`
+	if err := r.cluster.Reader(ctx).QueryRow(ctx, query, tenant.FromContext(ctx)).Scan(&version.Count, &version.LastModified); err != nil {
+		return models.UserListVersion{}, err
+	}
+	return version, nil
+}
+
+func scanUserResponses(rows pgx.Rows) ([]models.UserResponse, error) {
 	defer rows.Close()
 
 	var users []models.UserResponse
 	for rows.Next() {
 		var user models.UserResponse
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email); err != nil {
+		dest := append([]any{&user.ID, &user.Username, &user.Email}, profileDest(&user.UserProfile, &user.AvatarObjects)...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users, rows.Err()
+}
+
+// profileColumns - поля профиля и аватара в порядке profileDest
+const profileColumns = `display_name, first_name, last_name, phone, locale, timezone, metadata, avatar`
+
+// lookupProfileColumns - поля профиля из LEFT JOIN, для ненайденных пользователей - пустые значения
+const lookupProfileColumns = `COALESCE(u.display_name, ''), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+	COALESCE(u.phone, ''), COALESCE(u.locale, ''), COALESCE(u.timezone, ''), COALESCE(u.metadata, '{}'), u.avatar`
+
+func profileDest(profile *models.UserProfile, avatar **models.AvatarObjects) []any {
+	return []any{
+		&profile.DisplayName, &profile.FirstName, &profile.LastName, &profile.Phone,
+		&profile.Locale, &profile.Timezone, &profile.Metadata, avatar,
+	}
+}
+
+// metadataArg передает отсутствующие данные клиента как NULL, а не как JSON null
+func metadataArg(metadata map[string]any) any {
+	if metadata == nil {
+		return nil
+	}
+	return metadata
 }
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/logging"
+)
+
+// Ключ имеет вид ak_<префикс>.<секрет>: по префиксу ключ находится в базе, секрет сверяется с хэшем
+const (
+	apiKeyPrefix       = "ak_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	apiKeyPartsDivider = "."
+)
+
+var ErrInvalidAPIKey = fmt.Errorf("%w: invalid API key", models.ErrUnauthenticated)
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo, now: time.Now}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, input *models.APIKeyInput) (models.APIKeyCreated, error) {
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return models.APIKeyCreated{}, models.ValidationErrors{{Field: "ExpiresAt", Tag: models.TagFuture}}
+	}
+
+	prefix, err := randomString(apiKeyPrefixBytes)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+	prefix = apiKeyPrefix + prefix
+
+	secret, err := randomString(apiKeySecretBytes)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	tenantID := input.TenantID
+	if tenantID == 0 {
+		tenantID = tenant.DefaultID
+	}
+
+	key := models.APIKey{
+		TenantID:   tenantID,
+		Name:       input.Name,
+		Prefix:     prefix,
+		Scopes:     input.Scopes,
+		ExpiresAt:  input.ExpiresAt,
+		SecretHash: hashAPIKeySecret(secret),
+	}
+	key.ID, err = s.repo.CreateAPIKey(ctx, &key)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	logging.FromContext(ctx).WithField("api_key_id", key.ID).Info("API key created")
+	return models.APIKeyCreated{APIKey: key, Key: prefix + apiKeyPartsDivider + secret}, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id int) (models.APIKeyCreated, error) {
+	secret, err := randomString(apiKeySecretBytes)
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	key, err := s.repo.RotateAPIKey(ctx, id, hashAPIKeySecret(secret))
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+
+	logging.FromContext(ctx).WithField("api_key_id", id).Info("API key rotated")
+	return models.APIKeyCreated{APIKey: key, Key: key.Prefix + apiKeyPartsDivider + secret}, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	if err := s.repo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField("api_key_id", id).Warn("API key revoked")
+	return nil
+}
+
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (models.APIKey, error) {
+	prefix, secret, ok := strings.Cut(rawKey, apiKeyPartsDivider)
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefix) || secret == "" {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, ErrInvalidAPIKey
+		}
+		return models.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.SecretHash)) != 1 {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(s.now())) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		logging.FromContext(ctx).Errorf("Ошибка обновления времени использования API-ключа: %v", err)
+	}
+
+	return key, nil
+}
+
+// hashAPIKeySecret хэширует секрет ключа. Секрет случайный, поэтому медленный хэш не нужен
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
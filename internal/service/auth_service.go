@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/logging"
+	"simple_crud_go/pkg/utils"
+)
+
+// Области счетчиков неудачных входов
+const (
+	lockScopeAccount = "account"
+	lockScopeIP      = "ip"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrSessionRevoked     = fmt.Errorf("%w: session is revoked or expired", models.ErrUnauthenticated)
+)
+
+type authService struct {
+	users    repository.UserRepository
+	attempts repository.LoginAttemptRepository
+	sessions repository.SessionRepository
+	totp     TOTPService
+	hasher   utils.PasswordHasher
+	tokens   tokenIssuer
+	cfg      *configs.AuthConfig
+
+	// Хэш для сравнения, когда пользователь не найден, чтобы время ответа не выдавало существование логина
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+func NewAuthService(users repository.UserRepository, attempts repository.LoginAttemptRepository, sessions repository.SessionRepository, totp TOTPService, hasher utils.PasswordHasher, cfg *configs.AuthConfig) AuthService {
+	return &authService{
+		users:    users,
+		attempts: attempts,
+		sessions: sessions,
+		totp:     totp,
+		hasher:   hasher,
+		tokens:   tokenIssuer{sessions: sessions, cfg: cfg},
+		cfg:      cfg,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, input *models.LoginInput, client models.ClientInfo) (models.TokenResponse, error) {
+	// Сначала проверяем блокировку по IP, чтобы не нагружать базу перебором
+	if err := s.checkLock(ctx, lockScopeIP, client.IP); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	// Блокировка аккаунта проверяется и учитывается по имени до поиска пользователя,
+	// чтобы несуществующее имя отвечало так же, как существующее
+	username := normalizeUsername(input.Username)
+	accountKey := accountLockKey(ctx, username)
+	if err := s.checkLock(ctx, lockScopeAccount, accountKey); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.TokenResponse{}, err
+		}
+		s.dummyHashOnce.Do(func() { s.dummyHash, _ = s.hasher.Hash("dummy-password") })
+		s.hasher.Verify(input.Password, s.dummyHash)
+		err := s.registerFailure(ctx, lockScopeAccount, accountKey, ErrInvalidCredentials)
+		return models.TokenResponse{}, s.registerFailure(ctx, lockScopeIP, client.IP, err)
+	}
+
+	ok, err := s.hasher.Verify(input.Password, user.Password)
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("не удалось проверить пароль: %w", err)
+	}
+	if !ok {
+		err := s.registerFailure(ctx, lockScopeAccount, accountKey, ErrInvalidCredentials)
+		return models.TokenResponse{}, s.registerFailure(ctx, lockScopeIP, client.IP, err)
+	}
+
+	// Пароль верный, для подключенного TOTP нужен второй фактор
+	if user.TOTPEnabled {
+		if input.TOTPCode == "" && input.RecoveryCode == "" {
+			return models.TokenResponse{}, ErrTOTPRequired
+		}
+		ok, err := s.totp.VerifySecondFactor(ctx, user.ID, input.TOTPCode, input.RecoveryCode)
+		if err != nil {
+			return models.TokenResponse{}, err
+		}
+		if !ok {
+			err := s.registerFailure(ctx, lockScopeAccount, accountKey, ErrInvalidTOTPCode)
+			return models.TokenResponse{}, s.registerFailure(ctx, lockScopeIP, client.IP, err)
+		}
+	}
+
+	s.rehashIfNeeded(ctx, user.ID, input.Password, user.Password)
+
+	if err := s.attempts.Reset(ctx, lockScopeAccount, accountKey); err != nil {
+		logging.FromContext(ctx).Errorf("Ошибка сброса счетчика неудачных входов: %v", err)
+	}
+
+	return s.tokens.issueFor(ctx, user, client)
+}
+
+// rehashIfNeeded перехэширует пароль, если хэш создан другим алгоритмом или с устаревшими параметрами.
+// Ошибка не мешает входу: хэш обновится при следующем входе
+func (s *authService) rehashIfNeeded(ctx context.Context, userID int, password, encoded string) {
+	if !s.hasher.NeedsRehash(encoded) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.users.UpdatePassword(ctx, userID, hash)
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("user_id", userID).Errorf("Ошибка обновления хэша пароля: %v", err)
+		return
+	}
+
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Password hash upgraded")
+}
+
+func (s *authService) ParseToken(ctx context.Context, token string) (models.TokenClaims, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(s.cfg.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return models.TokenClaims{}, fmt.Errorf("%w: %w", models.ErrUnauthenticated, err)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return models.TokenClaims{}, fmt.Errorf("%w: invalid subject: %w", models.ErrUnauthenticated, err)
+	}
+
+	// Токен действует, пока не отозвана его сессия. Ошибка проверки сессии - сбой, а не отказ в доступе
+	active, err := s.sessions.TouchSession(ctx, claims.ID, userID)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+	if !active {
+		return models.TokenClaims{}, ErrSessionRevoked
+	}
+
+	// Токены, выпущенные до разделения по арендаторам, относятся к арендатору по умолчанию
+	tenantID := claims.TenantID
+	if tenantID == 0 {
+		tenantID = tenant.DefaultID
+	}
+
+	return models.TokenClaims{UserID: userID, TenantID: tenantID, SessionID: claims.ID, Scope: claims.Scope}, nil
+}
+
+func (s *authService) UnlockAccount(ctx context.Context, userID int) error {
+	user, err := s.users.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attempts.Reset(ctx, lockScopeAccount, accountLockKey(ctx, user.Username)); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField("user_id", userID).Warn("Account unlocked by admin")
+	return nil
+}
+
+// accountLockKey - ключ блокировки аккаунта: имя пользователя без учета регистра в пределах арендатора
+func accountLockKey(ctx context.Context, username string) string {
+	return strconv.Itoa(tenant.FromContext(ctx)) + ":" + strings.ToLower(username)
+}
+
+// checkLock возвращает LockedError, если вход для scope/key заблокирован
+func (s *authService) checkLock(ctx context.Context, scope, key string) error {
+	remaining, err := s.attempts.LockRemaining(ctx, scope, key)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return &LockedError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// registerFailure учитывает неудачную попытку и при превышении порога блокирует вход.
+// Возвращает loginErr, если учет прошел успешно.
+func (s *authService) registerFailure(ctx context.Context, scope, key string, loginErr error) error {
+	failures, lockouts, err := s.attempts.RegisterFailure(ctx, scope, key, s.cfg.Lockout.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if failures < s.cfg.Lockout.MaxFailures {
+		return loginErr
+	}
+
+	duration := lockoutDuration(&s.cfg.Lockout, lockouts)
+	if err := s.attempts.Lock(ctx, scope, key, duration); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"scope":    scope,
+		"key":      key,
+		"failures": failures,
+		"lockouts": lockouts + 1,
+		"duration": duration.String(),
+	}).Warn("Login locked after repeated failures")
+
+	return loginErr
+}
+
+// lockoutDuration удваивает длительность блокировки с каждой предыдущей блокировкой
+func lockoutDuration(cfg *configs.LockoutConfig, previousLockouts int) time.Duration {
+	multiplier := math.Pow(2, float64(previousLockouts))
+	duration := time.Duration(float64(cfg.BaseDuration) * multiplier)
+	if duration <= 0 || duration > cfg.MaxDuration {
+		return cfg.MaxDuration
+	}
+	return duration
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/utils"
+)
+
+// fakeLoginAttemptRepository хранит счетчики неудачных входов в памяти
+type fakeLoginAttemptRepository struct {
+	failures map[string]int
+	locked   map[string]time.Duration
+}
+
+func newFakeLoginAttemptRepository() *fakeLoginAttemptRepository {
+	return &fakeLoginAttemptRepository{failures: map[string]int{}, locked: map[string]time.Duration{}}
+}
+
+func (r *fakeLoginAttemptRepository) LockRemaining(_ context.Context, scope, key string) (time.Duration, error) {
+	return r.locked[scope+"/"+key], nil
+}
+
+func (r *fakeLoginAttemptRepository) RegisterFailure(_ context.Context, scope, key string, _ time.Duration) (int, int, error) {
+	r.failures[scope+"/"+key]++
+	return r.failures[scope+"/"+key], 0, nil
+}
+
+func (r *fakeLoginAttemptRepository) Lock(_ context.Context, scope, key string, duration time.Duration) error {
+	r.locked[scope+"/"+key] = duration
+	r.failures[scope+"/"+key] = 0
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) Reset(_ context.Context, scope, key string) error {
+	delete(r.locked, scope+"/"+key)
+	delete(r.failures, scope+"/"+key)
+	return nil
+}
+
+// loginUserRepository находит пользователей для входа по имени без учета регистра
+type loginUserRepository struct {
+	repository.UserRepository
+	users map[string]models.User
+}
+
+func (r *loginUserRepository) GetUserByUsername(_ context.Context, username string) (models.User, error) {
+	for name, user := range r.users {
+		if strings.EqualFold(name, username) {
+			return user, nil
+		}
+	}
+	return models.User{}, pgx.ErrNoRows
+}
+
+func TestAuthService_LockoutDoesNotRevealUsernames(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(&configs.PasswordHashingConfig{Algorithm: "bcrypt", BcryptCost: 4})
+	require.NoError(t, err)
+	hash, err := hasher.Hash("Str0ngPassw0rd")
+	require.NoError(t, err)
+
+	users := &loginUserRepository{users: map[string]models.User{"alice": {ID: 1, Username: "alice", Password: hash}}}
+	cfg := &configs.AuthConfig{Lockout: configs.LockoutConfig{
+		MaxFailures: 2, FailureWindow: time.Minute, BaseDuration: time.Minute, MaxDuration: time.Hour,
+	}}
+	auth := NewAuthService(users, newFakeLoginAttemptRepository(), nil, nil, hasher, cfg)
+	ctx := context.Background()
+
+	// IP каждый раз разный, чтобы блокировалось только имя
+	login := func(username, ip string) error {
+		_, err := auth.Login(ctx, &models.LoginInput{Username: username, Password: "wrong"}, models.ClientInfo{IP: ip})
+		return err
+	}
+
+	for _, username := range []string{"alice", "nobody"} {
+		assert.ErrorIs(t, login(username, username+"-1"), ErrInvalidCredentials)
+		assert.ErrorIs(t, login(username, username+"-2"), ErrInvalidCredentials)
+
+		// Существующее и несуществующее имя блокируются одинаково, регистр не обходит блокировку
+		var locked *LockedError
+		assert.ErrorAs(t, login(username, username+"-3"), &locked)
+		assert.ErrorAs(t, login(" "+strings.ToUpper(username), username+"-4"), &locked)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+
+	_ "image/gif"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/blob"
+	"simple_crud_go/pkg/logging"
+)
+
+// Ошибки проверки загружаемого аватара
+var (
+	ErrAvatarTooLarge        = errors.New("avatar image is too large")
+	ErrAvatarUnsupportedType = errors.New("unsupported avatar image type")
+	ErrAvatarInvalid         = errors.New("invalid avatar image")
+)
+
+// Допустимые типы изображений, тип определяется по содержимому, а не по заголовкам запроса
+var avatarContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type avatarService struct {
+	repo  repository.UserRepository
+	blobs blob.Store
+	cfg   *configs.AvatarConfig
+}
+
+func NewAvatarService(repo repository.UserRepository, blobs blob.Store, cfg *configs.AvatarConfig) AvatarService {
+	return &avatarService{repo: repo, blobs: blobs, cfg: cfg}
+}
+
+func (s *avatarService) SetAvatar(ctx context.Context, userID int, r io.Reader) (models.Avatar, error) {
+	// Не обрабатываем изображение для несуществующего пользователя
+	if _, err := s.repo.GetUserById(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Avatar{}, ErrUserNotFound
+		}
+		return models.Avatar{}, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBytes+1))
+	if err != nil {
+		return models.Avatar{}, err
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return models.Avatar{}, ErrAvatarTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !avatarContentTypes[contentType] {
+		return models.Avatar{}, fmt.Errorf("%w: %s", ErrAvatarUnsupportedType, contentType)
+	}
+
+	// Размеры проверяются до декодирования: небольшой файл может развернуться в огромное изображение
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return models.Avatar{}, fmt.Errorf("%w: %v", ErrAvatarInvalid, err)
+	}
+	if config.Width > s.cfg.MaxDimension || config.Height > s.cfg.MaxDimension {
+		return models.Avatar{}, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrAvatarInvalid, config.Width, config.Height, s.cfg.MaxDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.Avatar{}, fmt.Errorf("%w: %v", ErrAvatarInvalid, err)
+	}
+
+	objects, err := s.store(ctx, userID, img, contentType)
+	if err != nil {
+		return models.Avatar{}, err
+	}
+
+	previous, err := s.repo.SetUserAvatar(ctx, userID, objects)
+	if err != nil {
+		deleteAvatarFiles(ctx, s.blobs, objects)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Avatar{}, ErrUserNotFound
+		}
+		return models.Avatar{}, err
+	}
+	deleteAvatarFiles(ctx, s.blobs, previous)
+
+	return *s.AvatarURLs(objects), nil
+}
+
+// store сохраняет исходное изображение и миниатюры под новым префиксом, чтобы не перезаписывать
+// файлы, которые еще могут отдаваться по старым адресам
+func (s *avatarService) store(ctx context.Context, userID int, img image.Image, contentType string) (*models.AvatarObjects, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("avatars/%d/%d/%s/", tenant.FromContext(ctx), userID, hex.EncodeToString(suffix))
+
+	// Изображения перекодируются, так из файла удаляются метаданные (EXIF с геопозицией и т.п.).
+	// Фотографии остаются в JPEG, остальные форматы сохраняются в PNG с прозрачностью
+	ext, mime, encode := ".png", "image/png", func(w io.Writer, m image.Image) error { return png.Encode(w, m) }
+	if contentType == "image/jpeg" {
+		ext, mime, encode = ".jpg", "image/jpeg", func(w io.Writer, m image.Image) error {
+			return jpeg.Encode(w, m, &jpeg.Options{Quality: 90})
+		}
+	}
+
+	objects := &models.AvatarObjects{Original: prefix + "original" + ext, Thumbnails: make(map[int]string)}
+	files := map[string]image.Image{objects.Original: img}
+	for _, size := range s.cfg.ThumbnailSizes {
+		key := prefix + strconv.Itoa(size) + ext
+		objects.Thumbnails[size] = key
+		files[key] = thumbnail(img, size)
+	}
+
+	for key, file := range files {
+		var buf bytes.Buffer
+		err := encode(&buf, file)
+		if err == nil {
+			err = s.blobs.Put(ctx, key, &buf, mime)
+		}
+		if err != nil {
+			deleteAvatarFiles(ctx, s.blobs, objects)
+			return nil, fmt.Errorf("could not store avatar file %s: %w", key, err)
+		}
+	}
+	return objects, nil
+}
+
+func (s *avatarService) AvatarURLs(objects *models.AvatarObjects) *models.Avatar {
+	return avatarURLs(s.blobs, objects)
+}
+
+func avatarURLs(blobs blob.Store, objects *models.AvatarObjects) *models.Avatar {
+	if objects == nil {
+		return nil
+	}
+	avatar := &models.Avatar{URL: blobs.URL(objects.Original), Thumbnails: make(map[string]string, len(objects.Thumbnails))}
+	for size, key := range objects.Thumbnails {
+		avatar.Thumbnails[strconv.Itoa(size)] = blobs.URL(key)
+	}
+	return avatar
+}
+
+// withAvatarURLs заполняет адреса аватаров в списке пользователей
+func withAvatarURLs(blobs blob.Store, users []models.UserResponse) []models.UserResponse {
+	for i := range users {
+		users[i].Avatar = avatarURLs(blobs, users[i].AvatarObjects)
+	}
+	return users
+}
+
+// thumbnail вырезает из центра изображения квадрат и масштабирует его до size x size
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+	return dst
+}
+
+// deleteAvatarFiles удаляет файлы аватара. Ошибки только логируются: оставшийся файл не мешает работе
+func deleteAvatarFiles(ctx context.Context, blobs blob.Store, objects *models.AvatarObjects) {
+	if objects == nil {
+		return
+	}
+	keys := []string{objects.Original}
+	for _, key := range objects.Thumbnails {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if err := blobs.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Warnf("Could not delete avatar file %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/blob"
+)
+
+// fakeAvatarRepository хранит аватары пользователей в памяти
+type fakeAvatarRepository struct {
+	repository.UserRepository
+	avatars map[int]*models.AvatarObjects
+}
+
+func (r *fakeAvatarRepository) GetUserById(_ context.Context, id int) (models.User, error) {
+	avatar, ok := r.avatars[id]
+	if !ok {
+		return models.User{}, pgx.ErrNoRows
+	}
+	return models.User{ID: id, Avatar: avatar}, nil
+}
+
+func (r *fakeAvatarRepository) SetUserAvatar(_ context.Context, id int, avatar *models.AvatarObjects) (*models.AvatarObjects, error) {
+	previous := r.avatars[id]
+	r.avatars[id] = avatar
+	return previous, nil
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestAvatarService_SetAvatar(t *testing.T) {
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir, "/media")
+	require.NoError(t, err)
+	repo := &fakeAvatarRepository{avatars: map[int]*models.AvatarObjects{1: nil}}
+	cfg := &configs.AvatarConfig{MaxBytes: 1 << 20, MaxDimension: 1000, ThumbnailSizes: []int{32}}
+	avatars := NewAvatarService(repo, store, cfg)
+	ctx := context.Background()
+
+	avatar, err := avatars.SetAvatar(ctx, 1, bytes.NewReader(pngImage(t, 200, 100)))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(avatar.URL, "/media/avatars/1/1/"))
+	assert.Contains(t, avatar.Thumbnails, "32")
+
+	// Миниатюра квадратная заданного размера
+	first := repo.avatars[1]
+	file, err := os.Open(filepath.Join(dir, first.Thumbnails[32]))
+	require.NoError(t, err)
+	thumb, err := png.DecodeConfig(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, 32, thumb.Width)
+	assert.Equal(t, 32, thumb.Height)
+
+	// Новый аватар удаляет файлы прежнего
+	_, err = avatars.SetAvatar(ctx, 1, bytes.NewReader(pngImage(t, 50, 50)))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, first.Original))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAvatarService_SetAvatarRejected(t *testing.T) {
+	store, err := blob.NewLocalStore(t.TempDir(), "/media")
+	require.NoError(t, err)
+	repo := &fakeAvatarRepository{avatars: map[int]*models.AvatarObjects{1: nil}}
+	cfg := &configs.AvatarConfig{MaxBytes: 4096, MaxDimension: 100, ThumbnailSizes: []int{32}}
+	avatars := NewAvatarService(repo, store, cfg)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		userID int
+		data   []byte
+		err    error
+	}{
+		{name: "unknown user", userID: 2, data: pngImage(t, 10, 10), err: ErrUserNotFound},
+		{name: "too large", userID: 1, data: make([]byte, 4097), err: ErrAvatarTooLarge},
+		{name: "not an image", userID: 1, data: []byte("<html></html>"), err: ErrAvatarUnsupportedType},
+		{name: "too many pixels", userID: 1, data: pngImage(t, 101, 10), err: ErrAvatarInvalid},
+		{name: "truncated image", userID: 1, data: pngImage(t, 10, 10)[:40], err: ErrAvatarInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := avatars.SetAvatar(ctx, tt.userID, bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	assert.Nil(t, repo.avatars[1])
+}
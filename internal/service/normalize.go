@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
+
+	"simple_crud_go/internal/db/models"
+)
+
+// trimmedSpace - пробельные символы unicode.IsSpace, которые остаются после NFKC (остальные, как U+00A0
+// и U+3000, NFKC заменяет обычным пробелом). Набор совпадает с btrim в миграции 000011_normalized_usernames
+const trimmedSpace = " \t\n\v\f\r\u0085\u1680\u2028\u2029"
+
+// normalizeUsername приводит имя пользователя к форме NFKC и убирает пробелы по краям.
+// Регистр сохраняется для отображения, уникальность без учета регистра обеспечивает индекс по lower(username)
+func normalizeUsername(username string) string {
+	return strings.Trim(norm.NFKC.String(username), trimmedSpace)
+}
+
+// normalizeEmail приводит email к форме NFKC, убирает пробелы по краям и переводит домен в нижний регистр.
+// Локальная часть по RFC 5321 может различать регистр, поэтому сохраняется как есть
+func normalizeEmail(email string) string {
+	email = strings.Trim(norm.NFKC.String(email), trimmedSpace)
+	if at := strings.LastIndexByte(email, '@'); at >= 0 {
+		email = email[:at] + strings.ToLower(email[at:])
+	}
+	return email
+}
+
+// normalizeAll нормализует значения без повторов и запоминает, из каких исходных значений получено каждое
+func normalizeAll(values []string, normalize func(string) string) ([]string, map[string][]string) {
+	normalized := make([]string, 0, len(values))
+	inputs := make(map[string][]string, len(values))
+	for _, value := range values {
+		n := normalize(value)
+		if _, ok := inputs[n]; !ok {
+			normalized = append(normalized, n)
+		}
+		inputs[n] = append(inputs[n], value)
+	}
+	return normalized, inputs
+}
+
+// byOriginalInput переносит результаты по нормализованным значениям на исходные значения, из которых они получены
+func byOriginalInput[T any](byNormalized map[string]T, inputs map[string][]string) map[string]T {
+	result := make(map[string]T, len(inputs))
+	for n, originals := range inputs {
+		for _, original := range originals {
+			result[original] = byNormalized[n]
+		}
+	}
+	return result
+}
+
+// toValidationErrors переводит ошибки validator в models.ValidationErrors
+func toValidationErrors(err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+	violations := make(models.ValidationErrors, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		violations = append(violations, models.FieldError{Field: fe.Field(), Tag: fe.Tag(), Param: fe.Param()})
+	}
+	return violations
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	assert.Equal(t, "Alice", normalizeUsername("  Alice\t"))
+	// Полноширинные символы и лигатуры приводятся к обычным
+	assert.Equal(t, "Alice", normalizeUsername("Ａｌｉｃｅ"))
+	assert.Equal(t, "office", normalizeUsername("oﬃce"))
+}
+
+func TestTrimmedSpace_MatchesTrimSpace(t *testing.T) {
+	// После NFKC пробел по unicode.IsSpace либо становится обычным пробелом, либо входит в trimmedSpace,
+	// поэтому набор в миграции 000011 обрезает то же, что strings.TrimSpace
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !unicode.IsSpace(r) {
+			continue
+		}
+		normalized := norm.NFKC.String(string(r))
+		assert.True(t, normalized == " " || strings.ContainsRune(trimmedSpace, r), "U+%04X", r)
+	}
+	assert.Equal(t, "Alice", normalizeUsername("\u00a0\vAlice\u0085\u3000"))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "Alice@example.com", normalizeEmail(" Alice@EXAMPLE.Com "))
+	assert.Equal(t, "alice@example.com", normalizeEmail("ａｌｉｃｅ＠ｅｘａｍｐｌｅ．ｃｏｍ"))
+	assert.Equal(t, "not-an-email", normalizeEmail("not-an-email"))
+}
+
+func TestNormalizeAll_OriginalInputs(t *testing.T) {
+	normalized, inputs := normalizeAll([]string{" alice", "alice", "Bob"}, normalizeUsername)
+	assert.Equal(t, []string{"alice", "Bob"}, normalized)
+
+	// Результаты возвращаются по значениям в том виде, в каком их передал клиент
+	assert.Equal(t, map[string]int{" alice": 1, "alice": 1, "Bob": 0}, byOriginalInput(map[string]int{"alice": 1}, inputs))
+}
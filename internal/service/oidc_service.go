@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/oauth2"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/internal/tenant"
+	"simple_crud_go/pkg/logging"
+	"simple_crud_go/pkg/utils"
+)
+
+// Ограничения имени пользователя из models.User
+const (
+	usernameMinLength = 3
+	usernameMaxLength = 20
+	usernameSuffixLen = 5
+	usernameAttempts  = 5
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown OIDC provider")
+	ErrInvalidOIDCState    = errors.New("invalid or expired OIDC state")
+	ErrOIDCVerification    = errors.New("OIDC identity verification failed")
+	ErrOIDCEmailTaken      = errors.New("an account with this email already exists")
+	ErrOIDCIdentityLinked  = errors.New("external account is already linked to another user")
+	ErrOIDCSecondFactor    = errors.New("accounts with two-factor authentication must sign in with a password")
+)
+
+// oidcClaims - используемые поля ID-токена
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// oidcProvider - провайдер из конфигурации, discovery выполняется при первом обращении
+type oidcProvider struct {
+	name string
+	cfg  configs.OIDCProviderConfig
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcService struct {
+	repo      repository.OIDCRepository
+	hasher    utils.PasswordHasher
+	tokens    tokenIssuer
+	stateTTL  time.Duration
+	providers map[string]*oidcProvider
+	now       func() time.Time
+}
+
+func NewOIDCService(repo repository.OIDCRepository, sessions repository.SessionRepository, hasher utils.PasswordHasher, cfg *configs.AuthConfig) OIDCService {
+	providers := make(map[string]*oidcProvider, len(cfg.OIDC.Providers))
+	for name, providerCfg := range cfg.OIDC.Providers {
+		providers[name] = &oidcProvider{name: name, cfg: providerCfg}
+	}
+
+	return &oidcService{
+		repo:      repo,
+		hasher:    hasher,
+		tokens:    tokenIssuer{sessions: sessions, cfg: cfg},
+		stateTTL:  cfg.OIDC.StateTTL,
+		providers: providers,
+		now:       time.Now,
+	}
+}
+
+func (s *oidcService) OIDCAuthURL(ctx context.Context, provider string, linkUserID int) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	oauthCfg, _, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	loginState := &models.OIDCState{
+		State:        state,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		TenantID:     tenant.FromContext(ctx),
+		ExpiresAt:    s.now().Add(s.stateTTL),
+	}
+	if linkUserID != 0 {
+		loginState.LinkUserID = &linkUserID
+	}
+	if err := s.repo.SaveOIDCState(ctx, loginState); err != nil {
+		return "", "", err
+	}
+
+	authURL := oauthCfg.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(loginState.CodeVerifier))
+	return authURL, state, nil
+}
+
+func (s *oidcService) OIDCCallback(ctx context.Context, provider, code, state string, client models.ClientInfo) (models.OIDCResult, error) {
+	loginState, err := s.repo.ConsumeOIDCState(ctx, state)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.OIDCResult{}, ErrInvalidOIDCState
+		}
+		return models.OIDCResult{}, err
+	}
+	if loginState.Provider != provider || !loginState.ExpiresAt.After(s.now()) {
+		return models.OIDCResult{}, ErrInvalidOIDCState
+	}
+
+	// Возврат от провайдера не несет арендатора, он берется из состояния входа
+	ctx = tenant.WithID(ctx, loginState.TenantID)
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return models.OIDCResult{}, ErrUnknownOIDCProvider
+	}
+
+	identity, claims, err := p.exchange(ctx, code, loginState)
+	if err != nil {
+		return models.OIDCResult{}, err
+	}
+
+	log := logging.FromContext(ctx).WithField("provider", provider)
+
+	// Привязка внешнего аккаунта к вошедшему пользователю
+	if loginState.LinkUserID != nil {
+		identity.UserID = *loginState.LinkUserID
+		if err := s.repo.LinkIdentity(ctx, identity); err != nil {
+			return models.OIDCResult{}, identityLinkError(err)
+		}
+		log.WithField("user_id", identity.UserID).Info("External account linked")
+		return models.OIDCResult{LinkedUserID: identity.UserID}, nil
+	}
+
+	user, err := s.repo.GetUserByIdentity(ctx, provider, identity.Subject)
+	created := false
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		user, err = s.provisionUser(ctx, identity, claims)
+		if err != nil {
+			return models.OIDCResult{}, err
+		}
+		created = true
+		log.WithField("user_id", user.ID).Info("User provisioned from external account")
+	case err != nil:
+		return models.OIDCResult{}, err
+	}
+
+	// Второй фактор проверяется только при входе по паролю, обходить его через OIDC нельзя
+	if user.TOTPEnabled {
+		return models.OIDCResult{}, ErrOIDCSecondFactor
+	}
+
+	token, err := s.tokens.issueFor(ctx, user, client)
+	if err != nil {
+		return models.OIDCResult{}, err
+	}
+	return models.OIDCResult{Token: &token, Created: created}, nil
+}
+
+// provisionUser создает пользователя для нового внешнего аккаунта.
+// Подтвержденный email, уже занятый другим пользователем, не привязывается автоматически:
+// владелец аккаунта должен войти и привязать внешний аккаунт сам
+func (s *oidcService) provisionUser(ctx context.Context, identity *models.UserIdentity, claims *oidcClaims) (models.User, error) {
+	// Вход по паролю для таких пользователей невозможен, пока пароль не будет задан
+	password, err := randomString(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return models.User{}, fmt.Errorf("не удалось хэшировать пароль: %w", err)
+	}
+
+	base := usernameBase(claims)
+	for attempt := 0; attempt < usernameAttempts; attempt++ {
+		user := models.User{Username: base, Email: identity.Email, Password: hash, Role: models.RoleUser}
+		if attempt > 0 {
+			suffix, err := usernameSuffix()
+			if err != nil {
+				return models.User{}, err
+			}
+			user.Username = base[:min(len(base), usernameMaxLength-len(suffix))] + suffix
+		}
+
+		user.ID, err = s.repo.CreateUserWithIdentity(ctx, &user, identity)
+		if err == nil {
+			return user, nil
+		}
+
+		unique, constraint := uniqueViolation(err)
+		switch {
+		case unique && constraint == "users_username_key":
+			continue
+		case unique && constraint == "users_email_key":
+			return models.User{}, ErrOIDCEmailTaken
+		default:
+			return models.User{}, identityLinkError(err)
+		}
+	}
+
+	return models.User{}, errors.New("could not generate a unique username")
+}
+
+// discover загружает discovery-документ провайдера, неудачная попытка повторяется при следующем запросе
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("OIDC discovery for %s failed: %w", p.name, err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.cfg.Scopes...),
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+
+	return p.oauth2, p.verifier, nil
+}
+
+// exchange обменивает код на токены и проверяет ID-токен: подпись по JWKS провайдера, issuer, audience, срок и nonce
+func (p *oidcProvider) exchange(ctx context.Context, code string, state models.OIDCState) (*models.UserIdentity, *oidcClaims, error) {
+	oauthCfg, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := oauthCfg.Exchange(ctx, code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: code exchange: %v", ErrOIDCVerification, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: no id_token in token response", ErrOIDCVerification)
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrOIDCVerification, err)
+	}
+	if idToken.Nonce != state.Nonce {
+		return nil, nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCVerification)
+	}
+
+	claims := &oidcClaims{}
+	if err := idToken.Claims(claims); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrOIDCVerification, err)
+	}
+
+	identity := &models.UserIdentity{Provider: p.name, Subject: idToken.Subject}
+	// Неподтвержденный email не сохраняем, иначе им можно занять чужой адрес
+	if claims.EmailVerified {
+		identity.Email = normalizeEmail(claims.Email)
+	}
+
+	return identity, claims, nil
+}
+
+// usernameBase строит имя пользователя из preferred_username или email
+func usernameBase(claims *oidcClaims) string {
+	source := claims.PreferredUsername
+	if source == "" {
+		source, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(source) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			b.WriteRune(r)
+		}
+	}
+
+	base := b.String()
+	if len(base) > usernameMaxLength {
+		base = base[:usernameMaxLength]
+	}
+	if len(base) < usernameMinLength {
+		base = "user"
+	}
+	return base
+}
+
+func usernameSuffix() (string, error) {
+	raw := make([]byte, usernameSuffixLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	return "_" + strings.ToLower(encoded[:usernameSuffixLen-1]), nil
+}
+
+// identityLinkError переводит нарушение уникальности привязки в ErrOIDCIdentityLinked
+func identityLinkError(err error) error {
+	if ok, constraint := uniqueViolation(err); ok && constraint == "user_identities_provider_subject_key" {
+		return ErrOIDCIdentityLinked
+	}
+	return err
+}
+
+func uniqueViolation(err error) (bool, string) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return true, pgErr.ConstraintName
+	}
+	return false, ""
+}
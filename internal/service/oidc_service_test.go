@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/pkg/utils"
+)
+
+// mockOIDCProvider - локальный провайдер OpenID Connect: discovery, JWKS и token endpoint с проверкой PKCE
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu         sync.Mutex
+	challenges map[string]string // код авторизации -> code_challenge
+	nonces     map[string]string // код авторизации -> nonce
+	subject    string
+	claims     map[string]interface{}
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &mockOIDCProvider{key: key, challenges: map[string]string{}, nonces: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize имитирует вход пользователя у провайдера и возвращает код авторизации
+func (p *mockOIDCProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code := "code-" + u.Query().Get("state")
+	p.challenges[code] = u.Query().Get("code_challenge")
+	p.nonces[code] = u.Query().Get("nonce")
+	return code
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code := r.PostFormValue("code")
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if challenge, ok := p.challenges[code]; !ok || challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"sub":   p.subject,
+		"aud":   "client",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": p.nonces[code],
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, _ := token.SignedString(p.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+// fakeOIDCRepository хранит состояния и привязки в памяти
+type fakeOIDCRepository struct {
+	states     map[string]models.OIDCState
+	identities map[string]models.User
+	nextID     int
+}
+
+func newFakeOIDCRepository() *fakeOIDCRepository {
+	return &fakeOIDCRepository{states: map[string]models.OIDCState{}, identities: map[string]models.User{}}
+}
+
+func (r *fakeOIDCRepository) SaveOIDCState(_ context.Context, state *models.OIDCState) error {
+	r.states[state.State] = *state
+	return nil
+}
+
+func (r *fakeOIDCRepository) ConsumeOIDCState(_ context.Context, state string) (models.OIDCState, error) {
+	s, ok := r.states[state]
+	if !ok {
+		return models.OIDCState{}, pgx.ErrNoRows
+	}
+	delete(r.states, state)
+	return s, nil
+}
+
+func (r *fakeOIDCRepository) GetUserByIdentity(_ context.Context, provider, subject string) (models.User, error) {
+	user, ok := r.identities[provider+"/"+subject]
+	if !ok {
+		return models.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r *fakeOIDCRepository) LinkIdentity(_ context.Context, identity *models.UserIdentity) error {
+	r.identities[identity.Provider+"/"+identity.Subject] = models.User{ID: identity.UserID}
+	return nil
+}
+
+func (r *fakeOIDCRepository) CreateUserWithIdentity(_ context.Context, user *models.User, identity *models.UserIdentity) (int, error) {
+	r.nextID++
+	user.ID = r.nextID
+	r.identities[identity.Provider+"/"+identity.Subject] = *user
+	return user.ID, nil
+}
+
+func newTestOIDCService(t *testing.T, provider *mockOIDCProvider, repo *fakeOIDCRepository) OIDCService {
+	hasher, err := utils.NewPasswordHasher(&configs.PasswordHashingConfig{Algorithm: "bcrypt", BcryptCost: 4})
+	require.NoError(t, err)
+
+	return NewOIDCService(repo, newFakeSessionRepository(), hasher, &configs.AuthConfig{
+		JWTSecret: "test-secret-test-secret-test-secret",
+		TokenTTL:  time.Minute,
+		OIDC: configs.OIDCConfig{
+			StateTTL: time.Minute,
+			Providers: map[string]configs.OIDCProviderConfig{
+				"mock": {IssuerURL: provider.server.URL, ClientID: "client", RedirectURL: "http://localhost/callback"},
+			},
+		},
+	})
+}
+
+func TestOIDCService_ProvisionsAndLogsIn(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.subject = "subject-1"
+	provider.claims = map[string]interface{}{"email": "jane@example.com", "email_verified": true, "preferred_username": "Jane.Doe"}
+
+	repo := newFakeOIDCRepository()
+	s := newTestOIDCService(t, provider, repo)
+	ctx := context.Background()
+
+	authURL, state, err := s.OIDCAuthURL(ctx, "mock", 0)
+	require.NoError(t, err)
+
+	result, err := s.OIDCCallback(ctx, "mock", provider.authorize(t, authURL), state, models.ClientInfo{})
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	require.NotNil(t, result.Token)
+
+	user := repo.identities["mock/subject-1"]
+	assert.Equal(t, "janedoe", user.Username)
+	assert.Equal(t, "jane@example.com", user.Email)
+
+	// Повторный вход находит привязанного пользователя
+	authURL, state, err = s.OIDCAuthURL(ctx, "mock", 0)
+	require.NoError(t, err)
+
+	result, err = s.OIDCCallback(ctx, "mock", provider.authorize(t, authURL), state, models.ClientInfo{})
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	assert.Len(t, repo.identities, 1)
+
+	// State одноразовый
+	_, err = s.OIDCCallback(ctx, "mock", "code-"+state, state, models.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCService_RejectsWrongNonce(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.subject = "subject-1"
+
+	s := newTestOIDCService(t, provider, newFakeOIDCRepository())
+	ctx := context.Background()
+
+	authURL, state, err := s.OIDCAuthURL(ctx, "mock", 0)
+	require.NoError(t, err)
+
+	code := provider.authorize(t, authURL)
+	provider.nonces[code] = "forged"
+
+	_, err = s.OIDCCallback(ctx, "mock", code, state, models.ClientInfo{})
+	assert.ErrorIs(t, err, ErrOIDCVerification)
+}
+
+func TestOIDCService_LinksToCurrentUser(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.subject = "subject-2"
+
+	repo := newFakeOIDCRepository()
+	s := newTestOIDCService(t, provider, repo)
+	ctx := context.Background()
+
+	authURL, state, err := s.OIDCAuthURL(ctx, "mock", 42)
+	require.NoError(t, err)
+
+	result, err := s.OIDCCallback(ctx, "mock", provider.authorize(t, authURL), state, models.ClientInfo{})
+	require.NoError(t, err)
+	assert.Nil(t, result.Token)
+	assert.Equal(t, 42, result.LinkedUserID)
+	assert.Equal(t, 42, repo.identities["mock/subject-2"].ID)
+}
+
+func TestUsernameBase(t *testing.T) {
+	assert.Equal(t, "john", usernameBase(&oidcClaims{Email: "John@example.com"}))
+	assert.Equal(t, "user", usernameBase(&oidcClaims{PreferredUsername: "Ж"}))
+	assert.Len(t, usernameBase(&oidcClaims{PreferredUsername: "averyveryverylongusername"}), usernameMaxLength)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+)
+
+// Ограничение bcrypt: байты пароля после 72-го не учитываются
+const bcryptMaxPasswordBytes = 72
+
+// PasswordPolicy проверяет пароль по настраиваемым правилам и по списку утекших паролей
+type PasswordPolicy struct {
+	cfg      *configs.PasswordPolicyConfig
+	breached BreachedPasswordChecker
+}
+
+// NewPasswordPolicy создает политику паролей. breached может быть nil - проверка по утечкам отключена
+func NewPasswordPolicy(cfg *configs.PasswordPolicyConfig, breached BreachedPasswordChecker) *PasswordPolicy {
+	return &PasswordPolicy{cfg: cfg, breached: breached}
+}
+
+// Validate возвращает models.ValidationErrors со всеми нарушениями политики
+func (p *PasswordPolicy) Validate(ctx context.Context, password, username, email string) error {
+	var violations models.ValidationErrors
+	add := func(tag, param string) {
+		violations = append(violations, models.FieldError{Field: "Password", Tag: tag, Param: param})
+	}
+
+	if length := len([]rune(password)); length < p.cfg.MinLength {
+		add("min", strconv.Itoa(p.cfg.MinLength))
+	}
+	if len(password) > p.maxBytes() {
+		add(models.TagPasswordMaxBytes, strconv.Itoa(p.maxBytes()))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		add(models.TagPasswordUpper, "")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		add(models.TagPasswordLower, "")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		add(models.TagPasswordDigit, "")
+	}
+	if p.cfg.RequireSpecial && !hasSpecial {
+		add(models.TagPasswordSpecial, "")
+	}
+
+	if p.cfg.RejectUserInfo && containsUserInfo(password, username, email) {
+		add(models.TagPasswordUserInfo, "")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.IsBreached(ctx, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(models.TagPasswordBreached, "")
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// maxBytes не дает задать максимальную длину больше, чем учитывает bcrypt
+func (p *PasswordPolicy) maxBytes() int {
+	if p.cfg.MaxLength <= 0 || p.cfg.MaxLength > bcryptMaxPasswordBytes {
+		return bcryptMaxPasswordBytes
+	}
+	return p.cfg.MaxLength
+}
+
+// containsUserInfo проверяет, содержит ли пароль имя пользователя, email или его локальную часть
+func containsUserInfo(password, username, email string) bool {
+	password = strings.ToLower(password)
+
+	candidates := []string{username, email}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		candidates = append(candidates, local)
+	}
+
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		// Слишком короткие фрагменты дают ложные срабатывания
+		if len([]rune(candidate)) >= 3 && strings.Contains(password, candidate) {
+			return true
+		}
+	}
+	return false
+}
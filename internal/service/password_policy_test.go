@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+)
+
+func newTestPolicy(t *testing.T) *PasswordPolicy {
+	// SHA-1 от "Password123"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte("# test list\nB2E98AD6F6EB8508DD6A14CFA704BAD7F05F6FB1:42\n"), 0600)
+	require.NoError(t, err)
+
+	breached, err := LoadBreachedPasswords(path)
+	require.NoError(t, err)
+
+	return NewPasswordPolicy(&configs.PasswordPolicyConfig{
+		MinLength:      8,
+		MaxLength:      72,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RejectUserInfo: true,
+	}, breached)
+}
+
+func violationTags(err error) []string {
+	var tags []string
+	for _, fe := range err.(models.ValidationErrors) {
+		tags = append(tags, fe.Tag)
+	}
+	return tags
+}
+
+func TestPasswordPolicy_Valid(t *testing.T) {
+	policy := newTestPolicy(t)
+	assert.NoError(t, policy.Validate(context.Background(), "Correct7Horse", "testuser", "test@example.com"))
+}
+
+func TestPasswordPolicy_CharacterClasses(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	err := policy.Validate(context.Background(), "lowercaseonly", "testuser", "test@example.com")
+	assert.Equal(t, []string{models.TagPasswordUpper, models.TagPasswordDigit}, violationTags(err))
+}
+
+func TestPasswordPolicy_MaxLengthInBytes(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	// 40 кириллических букв - 80 байт в UTF-8
+	password := "A1" + strings.Repeat("ж", 40)
+	err := policy.Validate(context.Background(), password, "testuser", "test@example.com")
+	assert.Equal(t, []string{models.TagPasswordMaxBytes}, violationTags(err))
+}
+
+func TestPasswordPolicy_UserInfo(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	err := policy.Validate(context.Background(), "Alice2024!", "ALICE", "someone@example.com")
+	assert.Equal(t, []string{models.TagPasswordUserInfo}, violationTags(err))
+
+	err = policy.Validate(context.Background(), "Xjohn.doe9", "testuser", "john.doe@example.com")
+	assert.Equal(t, []string{models.TagPasswordUserInfo}, violationTags(err))
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	err := policy.Validate(context.Background(), "Password123", "testuser", "test@example.com")
+	assert.Equal(t, []string{models.TagPasswordBreached}, violationTags(err))
+}
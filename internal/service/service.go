@@ -2,23 +2,113 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"simple_crud_go/internal/db/models"
 	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/blob"
+	"simple_crud_go/pkg/utils"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, user *models.User) (int, error)
 	GetUserById(ctx context.Context, id int) (models.User, error)
+	// GetUserByUsername и GetUserByEmail ищут пользователя без учета регистра, pgx.ErrNoRows - не найден
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	// LookupUsers ищет пользователей по списку имен и email одним запросом
+	LookupUsers(ctx context.Context, input *models.UserLookupInput) (models.UserLookupResult, error)
 	UpdateUser(ctx context.Context, user *models.UserUpdate) error
 	DeleteUser(ctx context.Context, id int) error
 	ListUser(ctx context.Context) ([]models.UserResponse, error)
+	// ListUserPage возвращает до limit подходящих под фильтр пользователей с ID больше afterID по возрастанию ID
+	ListUserPage(ctx context.Context, filter models.UserFilter, afterID, limit int) ([]models.UserResponse, error)
+	// GetUsersByIDs возвращает пользователей с указанными ID в произвольном порядке, отсутствующие ID пропускаются
+	GetUsersByIDs(ctx context.Context, ids []int) ([]models.UserResponse, error)
+	// GetUserListVersion возвращает версию списка пользователей для проверки актуальности без его выборки
+	GetUserListVersion(ctx context.Context) (models.UserListVersion, error)
+}
+
+type AvatarService interface {
+	// SetAvatar проверяет тип, размер и размеры изображения, создает миниатюры и заменяет аватар пользователя
+	SetAvatar(ctx context.Context, userID int, image io.Reader) (models.Avatar, error)
+	// AvatarURLs возвращает адреса файлов аватара, nil - аватара нет
+	AvatarURLs(objects *models.AvatarObjects) *models.Avatar
+}
+
+type AuthService interface {
+	// Login проверяет логин и пароль с учетом блокировок и возвращает токен доступа
+	Login(ctx context.Context, input *models.LoginInput, client models.ClientInfo) (models.TokenResponse, error)
+	// ParseToken проверяет токен доступа и его сессию и возвращает данные из токена
+	ParseToken(ctx context.Context, token string) (models.TokenClaims, error)
+	// UnlockAccount снимает блокировку входа с аккаунта
+	UnlockAccount(ctx context.Context, userID int) error
+}
+
+type TOTPService interface {
+	// EnrollTOTP создает новый секрет TOTP, до подтверждения он не действует
+	EnrollTOTP(ctx context.Context, userID int) (models.TOTPEnrollment, error)
+	// ConfirmTOTP включает TOTP после проверки первого кода и возвращает одноразовые коды восстановления
+	ConfirmTOTP(ctx context.Context, userID int, code string) (models.RecoveryCodesResponse, error)
+	// VerifySecondFactor проверяет при входе код TOTP или, если он не передан, код восстановления
+	VerifySecondFactor(ctx context.Context, userID int, code, recoveryCode string) (bool, error)
+}
+
+type APIKeyService interface {
+	// CreateAPIKey создает ключ, полный ключ возвращается только здесь
+	CreateAPIKey(ctx context.Context, input *models.APIKeyInput) (models.APIKeyCreated, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RotateAPIKey выпускает новый секрет, старый сразу перестает действовать
+	RotateAPIKey(ctx context.Context, id int) (models.APIKeyCreated, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// AuthenticateAPIKey проверяет ключ из заголовка Authorization: ApiKey
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (models.APIKey, error)
+}
+
+type OIDCService interface {
+	// OIDCAuthURL начинает вход через провайдера и возвращает адрес его страницы входа и state.
+	// linkUserID != 0 - внешний аккаунт будет привязан к этому пользователю
+	OIDCAuthURL(ctx context.Context, provider string, linkUserID int) (authURL, state string, err error)
+	// OIDCCallback завершает вход по коду от провайдера: вход, создание пользователя или привязка
+	OIDCCallback(ctx context.Context, provider, code, state string, client models.ClientInfo) (models.OIDCResult, error)
+}
+
+type SessionService interface {
+	// ListSessions возвращает действующие сессии пользователя, текущая сессия отмечается
+	ListSessions(ctx context.Context, userID int, currentSessionID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	// RevokeAllSessions завершает все сессии пользователя
+	RevokeAllSessions(ctx context.Context, userID int) (int, error)
+}
+
+// Services объединяет сервисы приложения для обработчиков
+type Services struct {
+	UserService
+	AvatarService
+	AuthService
+	TOTPService
+	APIKeyService
+	OIDCService
+	SessionService
+}
+
+// LockedError - вход временно заблокирован после серии неудачных попыток
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return "login is temporarily locked"
 }
 
 type Service struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	policy *PasswordPolicy
+	hasher utils.PasswordHasher
+	blobs  blob.Store
 }
 
-func NewService(repo repository.UserRepository) UserService {
-	return &Service{repo: repo}
+func NewService(repo repository.UserRepository, policy *PasswordPolicy, hasher utils.PasswordHasher, blobs blob.Store) UserService {
+	return &Service{repo: repo, policy: policy, hasher: hasher, blobs: blobs}
 }
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/logging"
+)
+
+type sessionService struct {
+	repo repository.SessionRepository
+}
+
+func NewSessionService(repo repository.SessionRepository) SessionService {
+	return &sessionService{repo: repo}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userID int, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	if err := s.repo.RevokeSession(ctx, sessionID, userID); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Info("Session revoked")
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userID int) (int, error) {
+	revoked, err := s.repo.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user_id": userID,
+		"revoked": revoked,
+	}).Info("All sessions revoked")
+	return revoked, nil
+}
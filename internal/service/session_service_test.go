@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+)
+
+// fakeSessionRepository хранит сессии в памяти
+type fakeSessionRepository struct {
+	sessions map[string]models.Session
+	revoked  map[string]bool
+}
+
+func newFakeSessionRepository() *fakeSessionRepository {
+	return &fakeSessionRepository{sessions: map[string]models.Session{}, revoked: map[string]bool{}}
+}
+
+func (r *fakeSessionRepository) CreateSession(_ context.Context, session *models.Session) error {
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeSessionRepository) TouchSession(_ context.Context, id string, userID int) (bool, error) {
+	session, ok := r.sessions[id]
+	return ok && session.UserID == userID && !r.revoked[id], nil
+}
+
+func (r *fakeSessionRepository) ListSessions(_ context.Context, userID int) ([]models.Session, error) {
+	var sessions []models.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && !r.revoked[session.ID] {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepository) RevokeSession(_ context.Context, id string, userID int) error {
+	if session, ok := r.sessions[id]; !ok || session.UserID != userID {
+		return pgx.ErrNoRows
+	}
+	r.revoked[id] = true
+	return nil
+}
+
+func (r *fakeSessionRepository) RevokeUserSessions(_ context.Context, userID int) (int, error) {
+	revoked := 0
+	for id, session := range r.sessions {
+		if session.UserID == userID && !r.revoked[id] {
+			r.revoked[id] = true
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func TestAuthService_ParseTokenChecksSession(t *testing.T) {
+	sessions := newFakeSessionRepository()
+	cfg := &configs.AuthConfig{JWTSecret: "test-secret-test-secret-test-secret", TokenTTL: time.Minute}
+	auth := NewAuthService(nil, nil, sessions, nil, nil, cfg)
+	tokens := tokenIssuer{sessions: sessions, cfg: cfg}
+	ctx := context.Background()
+
+	first, err := tokens.issueFor(ctx, models.User{ID: 7}, models.ClientInfo{IP: "10.0.0.1", UserAgent: "curl"})
+	require.NoError(t, err)
+	second, err := tokens.issueFor(ctx, models.User{ID: 7}, models.ClientInfo{IP: "10.0.0.2", UserAgent: "firefox"})
+	require.NoError(t, err)
+
+	claims, err := auth.ParseToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+
+	// Отозванная сессия делает токен недействительным, остальные сессии продолжают работать
+	require.NoError(t, NewSessionService(sessions).RevokeSession(ctx, 7, claims.SessionID))
+
+	_, err = auth.ParseToken(ctx, first.AccessToken)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	_, err = auth.ParseToken(ctx, second.AccessToken)
+	assert.NoError(t, err)
+
+	// Выход на всех устройствах
+	revoked, err := NewSessionService(sessions).RevokeAllSessions(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+
+	_, err = auth.ParseToken(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+}
+
+func TestSessionService_MarksCurrentSession(t *testing.T) {
+	sessions := newFakeSessionRepository()
+	sessions.sessions["a"] = models.Session{ID: "a", UserID: 1}
+	sessions.sessions["b"] = models.Session{ID: "b", UserID: 1}
+	sessions.sessions["c"] = models.Session{ID: "c", UserID: 2}
+
+	list, err := NewSessionService(sessions).ListSessions(context.Background(), 1, "b")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, session := range list {
+		assert.Equal(t, session.ID == "b", session.Current)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+)
+
+// Длина user agent, сохраняемого в сессии
+const maxUserAgentLength = 512
+
+// tokenClaims - содержимое токена доступа, пустая область означает полный доступ
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope    string `json:"scope,omitempty"`
+	TenantID int    `json:"tid,omitempty"`
+}
+
+// tokenIssuer открывает сессию и выпускает токен доступа при входе по паролю и через OIDC
+type tokenIssuer struct {
+	sessions repository.SessionRepository
+	cfg      *configs.AuthConfig
+}
+
+// issueFor открывает сессию пользователя и выпускает токен.
+// Администратор без второго фактора получает токен только для подключения TOTP
+func (t tokenIssuer) issueFor(ctx context.Context, user models.User, client models.ClientInfo) (models.TokenResponse, error) {
+	scope := models.ScopeFull
+	if user.Role == models.RoleAdmin && !user.TOTPEnabled {
+		scope = models.ScopeTOTPEnroll
+	}
+
+	sessionID, err := randomString(24)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		IP:        client.IP,
+		UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+		ExpiresAt: now.Add(t.cfg.TokenTTL),
+	}
+	if err := t.sessions.CreateSession(ctx, session); err != nil {
+		return models.TokenResponse{}, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
+
+	return t.issue(user.ID, user.TenantID, sessionID, scope, now)
+}
+
+func (t tokenIssuer) issue(userID, tenantID int, sessionID, scope string, now time.Time) (models.TokenResponse, error) {
+	claims := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.cfg.TokenTTL)),
+		},
+		Scope:    scope,
+		TenantID: tenantID,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(t.cfg.JWTSecret))
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("не удалось подписать токен: %w", err)
+	}
+
+	return models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(t.cfg.TokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// truncate обрезает строку до max байт, не разрывая символы
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
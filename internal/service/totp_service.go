@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/logging"
+	"simple_crud_go/pkg/utils"
+)
+
+// Параметры TOTP по RFC 6238, которые поддерживают все приложения-аутентификаторы
+const (
+	totpPeriod = 30
+	totpDigits = otp.DigitsSix
+	qrCodeSize = 256
+)
+
+var (
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor enrollment was not started")
+)
+
+type totpService struct {
+	repo  repository.TOTPRepository
+	users repository.UserRepository
+	box   *utils.SecretBox
+	cfg   *configs.TOTPConfig
+	now   func() time.Time
+}
+
+func NewTOTPService(repo repository.TOTPRepository, users repository.UserRepository, box *utils.SecretBox, cfg *configs.TOTPConfig) TOTPService {
+	return &totpService{repo: repo, users: users, box: box, cfg: cfg, now: time.Now}
+}
+
+func (s *totpService) EnrollTOTP(ctx context.Context, userID int) (models.TOTPEnrollment, error) {
+	user, err := s.users.GetUserById(ctx, userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.cfg.Issuer,
+		AccountName: user.Username,
+		Period:      totpPeriod,
+		Digits:      totpDigits,
+	})
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("не удалось создать секрет TOTP: %w", err)
+	}
+
+	encrypted, err := s.box.Encrypt(key.Secret())
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("не удалось зашифровать секрет TOTP: %w", err)
+	}
+
+	// Секрет не перезаписывается, если TOTP уже включен
+	stored, err := s.repo.SetTOTPSecret(ctx, userID, encrypted)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	if !stored {
+		return models.TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+
+	qrCode, err := qrCodePNG(key)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	return models.TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: qrCode,
+	}, nil
+}
+
+func (s *totpService) ConfirmTOTP(ctx context.Context, userID int, code string) (models.RecoveryCodesResponse, error) {
+	state, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	if state.Enabled {
+		return models.RecoveryCodesResponse{}, ErrTOTPAlreadyEnabled
+	}
+	if state.Secret == "" {
+		return models.RecoveryCodesResponse{}, ErrTOTPNotEnrolled
+	}
+
+	secret, err := s.box.Decrypt(state.Secret)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, fmt.Errorf("не удалось расшифровать секрет TOTP: %w", err)
+	}
+
+	step, ok := s.matchCode(secret, code)
+	if !ok {
+		return models.RecoveryCodesResponse{}, ErrInvalidTOTPCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes(s.cfg.RecoveryCodes)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	if err := s.repo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Two-factor authentication enabled")
+	return models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *totpService) VerifySecondFactor(ctx context.Context, userID int, code, recoveryCode string) (bool, error) {
+	if code == "" {
+		used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(recoveryCode))
+		if used {
+			logging.FromContext(ctx).WithField("user_id", userID).Warn("Recovery code used for login")
+		}
+		return used, err
+	}
+
+	state, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := s.box.Decrypt(state.Secret)
+	if err != nil {
+		return false, fmt.Errorf("не удалось расшифровать секрет TOTP: %w", err)
+	}
+
+	step, ok := s.matchCode(secret, code)
+	if !ok {
+		return false, nil
+	}
+
+	// Один и тот же код нельзя использовать повторно
+	return s.repo.UseTOTPStep(ctx, userID, step)
+}
+
+// matchCode ищет код в окне ±skew шагов и возвращает номер совпавшего шага
+func (s *totpService) matchCode(secret, code string) (int64, bool) {
+	now := s.now()
+	opts := totp.ValidateOpts{Period: totpPeriod, Digits: totpDigits, Algorithm: otp.AlgorithmSHA1}
+
+	skew := int(s.cfg.Skew)
+	for offset := -skew; offset <= skew; offset++ {
+		at := now.Add(time.Duration(offset*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, at, opts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return at.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+func qrCodePNG(key *otp.Key) (string, error) {
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать QR-код: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("не удалось закодировать QR-код: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// generateRecoveryCodes возвращает коды вида xxxxx-xxxxx и их хэши для хранения
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(raw)
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode хэширует код восстановления. Коды случайные, поэтому медленный хэш не нужен
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func newTestTOTPService(now time.Time) *totpService {
+	return &totpService{
+		cfg: &configs.TOTPConfig{Skew: 1},
+		now: func() time.Time { return now },
+	}
+}
+
+func TestTOTPService_MatchCode(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := newTestTOTPService(now)
+	opts := totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+	code, err := totp.GenerateCodeCustom(testTOTPSecret, now, opts)
+	require.NoError(t, err)
+
+	step, ok := s.matchCode(testTOTPSecret, code)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/totpPeriod, step)
+
+	// Код предыдущего шага принимается в пределах skew
+	previous, err := totp.GenerateCodeCustom(testTOTPSecret, now.Add(-totpPeriod*time.Second), opts)
+	require.NoError(t, err)
+	step, ok = s.matchCode(testTOTPSecret, previous)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/totpPeriod-1, step)
+
+	// Код за пределами skew отклоняется
+	stale, err := totp.GenerateCodeCustom(testTOTPSecret, now.Add(-3*totpPeriod*time.Second), opts)
+	require.NoError(t, err)
+	_, ok = s.matchCode(testTOTPSecret, stale)
+	assert.False(t, ok)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	require.Len(t, hashes, 10)
+
+	for i, code := range codes {
+		assert.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}$`, code)
+		// Код принимается без дефиса и в верхнем регистре
+		assert.Equal(t, hashes[i], hashRecoveryCode(code))
+		assert.Equal(t, hashes[i], hashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))+" "))
+	}
+}
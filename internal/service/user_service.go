@@ -6,18 +6,31 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
-	logger "github.com/sirupsen/logrus"
 
 	"simple_crud_go/internal/db/models"
-	"simple_crud_go/pkg/utils"
+	"simple_crud_go/pkg/logging"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 func (s *Service) CreateUser(ctx context.Context, user *models.User) (int, error) {
+	// Нормализация может изменить длину значений, поэтому данные проверяются повторно
+	user.Username = normalizeUsername(user.Username)
+	user.Email = normalizeEmail(user.Email)
+	if err := user.Validate(); err != nil {
+		return 0, toValidationErrors(err)
+	}
+
+	// Проверяем пароль по политике паролей
+	if err := s.policy.Validate(ctx, user.Password, user.Username, user.Email); err != nil {
+		return 0, err
+	}
+
 	// Хэшируем пароль пользователя
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := s.hasher.Hash(user.Password)
 	if err != nil {
-		// Логируем ошибку (если у вас есть настроенный логгер)
-		logger.Errorf("Ошибка хэширования пароля: %v", err)
+		// Логируем ошибку с идентификатором запроса
+		logging.FromContext(ctx).Errorf("Ошибка хэширования пароля: %v", err)
 		// Возвращаем ошибку вверх по цепочке
 		return 0, fmt.Errorf("не удалось хэшировать пароль: %w", err)
 	}
@@ -33,32 +46,89 @@ func (s *Service) GetUserById(ctx context.Context, id int) (models.User, error)
 	return s.repo.GetUserById(ctx, id)
 }
 
-func (s *Service) UpdateUser(ctx context.Context, user *models.UserUpdate) error {
-	// Проверяем, существует ли пользователь
-	existingUser, err := s.repo.GetUserById(ctx, user.ID)
+func (s *Service) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	return s.repo.FindUserByUsername(ctx, normalizeUsername(username))
+}
+
+func (s *Service) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return s.repo.FindUserByEmail(ctx, normalizeEmail(email))
+}
+
+func (s *Service) LookupUsers(ctx context.Context, input *models.UserLookupInput) (models.UserLookupResult, error) {
+	// Ищем по нормализованным значениям, а результаты возвращаем по идентификаторам в том виде, в каком их передал клиент
+	usernames, usernameInputs := normalizeAll(input.Usernames, normalizeUsername)
+	emails, emailInputs := normalizeAll(input.Emails, normalizeEmail)
+
+	found, err := s.repo.LookupUsers(ctx, usernames, emails)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("user not found")
-		}
-		return err
+		return models.UserLookupResult{}, err
 	}
 
-	// Если данные не предоставлены, оставляем старые
-	if user.Username == "" {
-		user.Username = existingUser.Username
+	for _, users := range []map[string]*models.UserResponse{found.Usernames, found.Emails} {
+		for _, user := range users {
+			if user != nil {
+				user.Avatar = avatarURLs(s.blobs, user.AvatarObjects)
+			}
+		}
 	}
-	if user.Email == "" {
-		user.Email = existingUser.Email
+	return models.UserLookupResult{
+		Usernames: byOriginalInput(found.Usernames, usernameInputs),
+		Emails:    byOriginalInput(found.Emails, emailInputs),
+	}, nil
+}
+
+func (s *Service) UpdateUser(ctx context.Context, user *models.UserUpdate) error {
+	user.Username = normalizeUsername(user.Username)
+	user.Email = normalizeEmail(user.Email)
+	if err := user.Validate(); err != nil {
+		return toValidationErrors(err)
 	}
 
-	// Обновляем данные
-	return s.repo.UpdateUser(ctx, user)
+	// Пустые имя и email сохраняют текущие значения. Их подставляет сам UPDATE в основной базе:
+	// прочитанная с реплики или из кэша строка может быть устаревшей
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *Service) DeleteUser(ctx context.Context, id int) error {
-	return s.repo.DeleteUser(ctx, id)
+	// Пользователь другого арендатора не затрагивается. Аватар удаленной строки удаляется вместе с ней
+	avatar, err := s.repo.DeleteUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	deleteAvatarFiles(ctx, s.blobs, avatar)
+	return nil
 }
 
 func (s *Service) ListUser(ctx context.Context) ([]models.UserResponse, error) {
-	return s.repo.ListUser(ctx)
+	users, err := s.repo.ListUser(ctx)
+	return withAvatarURLs(s.blobs, users), err
+}
+
+func (s *Service) ListUserPage(ctx context.Context, filter models.UserFilter, afterID, limit int) ([]models.UserResponse, error) {
+	// Фильтр сравнивается с нормализованными значениями
+	filter.Username = normalizeUsername(filter.Username)
+	filter.Email = normalizeEmail(filter.Email)
+	users, err := s.repo.ListUserPage(ctx, filter, afterID, limit)
+	return withAvatarURLs(s.blobs, users), err
+}
+
+func (s *Service) GetUsersByIDs(ctx context.Context, ids []int) ([]models.UserResponse, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	users, err := s.repo.GetUsersByIDs(ctx, ids)
+	return withAvatarURLs(s.blobs, users), err
+}
+
+func (s *Service) GetUserListVersion(ctx context.Context) (models.UserListVersion, error) {
+	return s.repo.GetUserListVersion(ctx)
 }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+	"simple_crud_go/internal/db/models"
+	"simple_crud_go/internal/repository"
+	"simple_crud_go/pkg/utils"
+)
+
+// fakeUserRepository хранит пользователей в памяти и, как индексы users_username_key и users_email_key,
+// не допускает имен и email, совпадающих без учета регистра
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[int]models.User
+
+	lookedUp []string // значения, переданные в LookupUsers
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{users: map[int]models.User{}}
+}
+
+func (r *fakeUserRepository) CreateUser(_ context.Context, user *models.User) (int, error) {
+	if err := r.checkUnique(0, user.Username, user.Email); err != nil {
+		return 0, err
+	}
+	id := len(r.users) + 1
+	r.users[id] = models.User{ID: id, Username: user.Username, Email: user.Email, Password: user.Password}
+	return id, nil
+}
+
+func (r *fakeUserRepository) GetUserById(_ context.Context, id int) (models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+// UpdateUser, как и запрос в базе, оставляет текущие значения для пустых имени и email
+func (r *fakeUserRepository) UpdateUser(_ context.Context, update *models.UserUpdate) error {
+	user, ok := r.users[update.ID]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	if update.Username != "" {
+		user.Username = update.Username
+	}
+	if update.Email != "" {
+		user.Email = update.Email
+	}
+	if err := r.checkUnique(update.ID, user.Username, user.Email); err != nil {
+		return err
+	}
+	r.users[update.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) LookupUsers(_ context.Context, usernames, emails []string) (models.UserLookupResult, error) {
+	r.lookedUp = append(append(r.lookedUp, usernames...), emails...)
+
+	find := func(values []string, field func(models.User) string) map[string]*models.UserResponse {
+		found := make(map[string]*models.UserResponse, len(values))
+		for _, value := range values {
+			found[value] = nil
+			for _, user := range r.users {
+				if strings.EqualFold(field(user), value) {
+					found[value] = &models.UserResponse{ID: user.ID, Username: user.Username, Email: user.Email}
+				}
+			}
+		}
+		return found
+	}
+	return models.UserLookupResult{
+		Usernames: find(usernames, func(u models.User) string { return u.Username }),
+		Emails:    find(emails, func(u models.User) string { return u.Email }),
+	}, nil
+}
+
+func (r *fakeUserRepository) checkUnique(id int, username, email string) error {
+	for _, user := range r.users {
+		if user.ID == id {
+			continue
+		}
+		if strings.EqualFold(user.Username, username) {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"}
+		}
+		if strings.EqualFold(user.Email, email) {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}
+		}
+	}
+	return nil
+}
+
+func newTestUserService(t *testing.T, repo repository.UserRepository) UserService {
+	hasher, err := utils.NewPasswordHasher(&configs.PasswordHashingConfig{Algorithm: "bcrypt", BcryptCost: 4})
+	require.NoError(t, err)
+	return NewService(repo, newTestPolicy(t), hasher, nil)
+}
+
+func TestCreateUser_DuplicateUsernameIgnoresCase(t *testing.T) {
+	users := newTestUserService(t, newFakeUserRepository())
+	ctx := context.Background()
+
+	_, err := users.CreateUser(ctx, &models.User{Username: "alice", Email: "alice@example.com", Password: "Str0ngPassw0rd"})
+	require.NoError(t, err)
+
+	// Имя отличается регистром, полноширинными символами и пробелами по краям
+	_, err = users.CreateUser(ctx, &models.User{Username: " Ａｌｉｃｅ ", Email: "other@example.com", Password: "Str0ngPassw0rd"})
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "23505", pgErr.Code)
+	assert.Equal(t, "users_username_key", pgErr.ConstraintName)
+}
+
+func TestUpdateUser_Normalizes(t *testing.T) {
+	repo := newFakeUserRepository()
+	repo.users[1] = models.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+	users := newTestUserService(t, repo)
+
+	err := users.UpdateUser(context.Background(), &models.UserUpdate{ID: 1, Username: "\tＡｌｉｃｅ ", Email: " Alice@EXAMPLE.Com\v"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", repo.users[1].Username)
+	assert.Equal(t, "Alice@example.com", repo.users[1].Email)
+
+	// Пустой email сохраняет текущий, сервис не подставляет его из прочитанной строки
+	update := &models.UserUpdate{ID: 1, Username: "alice"}
+	require.NoError(t, users.UpdateUser(context.Background(), update))
+	assert.Empty(t, update.Email)
+	assert.Equal(t, "Alice@example.com", repo.users[1].Email)
+
+	// Пользователь, которого нет у арендатора, не найден
+	err = users.UpdateUser(context.Background(), &models.UserUpdate{ID: 2, Username: "bob"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestLookupUsers_KeyedByRequestedIdentifier(t *testing.T) {
+	repo := newFakeUserRepository()
+	repo.users[1] = models.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+	repo.users[2] = models.User{ID: 2, Username: "bob", Email: "Bob@example.com"}
+	users := newTestUserService(t, repo)
+
+	result, err := users.LookupUsers(context.Background(), &models.UserLookupInput{
+		Usernames: []string{" alice", "alice", "ＢＯＢ", "nobody"},
+		Emails:    []string{"bob@EXAMPLE.com ", "nobody@example.com"},
+	})
+	require.NoError(t, err)
+
+	// Одинаковые после нормализации идентификаторы ищутся один раз
+	assert.Equal(t, []string{"alice", "BOB", "nobody", "bob@example.com", "nobody@example.com"}, repo.lookedUp)
+
+	// Результаты возвращаются по каждому идентификатору в том виде, в каком его передал клиент
+	assert.Len(t, result.Usernames, 4)
+	assert.Equal(t, 1, result.Usernames[" alice"].ID)
+	assert.Equal(t, 1, result.Usernames["alice"].ID)
+	assert.Equal(t, 2, result.Usernames["ＢＯＢ"].ID)
+	assert.Contains(t, result.Usernames, "nobody")
+	assert.Nil(t, result.Usernames["nobody"])
+
+	assert.Len(t, result.Emails, 2)
+	assert.Equal(t, 2, result.Emails["bob@EXAMPLE.com "].ID)
+	assert.Contains(t, result.Emails, "nobody@example.com")
+	assert.Nil(t, result.Emails["nobody@example.com"])
+}
@@ -0,0 +1,21 @@
+package tenant
+
+import "context"
+
+// DefaultID - арендатор по умолчанию, к нему относятся пользователи, созданные до разделения по арендаторам
+const DefaultID = 1
+
+type key struct{}
+
+// WithID привязывает к контексту арендатора, в рамках которого выполняется запрос
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext возвращает арендатора запроса, для контекстов без арендатора - DefaultID
+func FromContext(ctx context.Context) int {
+	if id, ok := ctx.Value(key{}).(int); ok {
+		return id
+	}
+	return DefaultID
+}
@@ -0,0 +1,20 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrInvalidKey - ключ пустой или выходит за пределы хранилища
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store хранит файлы по ключам вида "avatars/1/42/original.png"
+type Store interface {
+	// Put сохраняет содержимое под ключом, существующий файл заменяется
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Delete удаляет файл, отсутствие ключа ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// URL возвращает адрес, по которому клиенты получают файл
+	URL(key string) string
+}
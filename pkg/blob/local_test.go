@@ -0,0 +1,41 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_PutDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "/media/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "avatars/1/original.png", strings.NewReader("image"), "image/png"))
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "original.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+	assert.Equal(t, "/media/avatars/1/original.png", store.URL("avatars/1/original.png"))
+
+	require.NoError(t, store.Delete(ctx, "avatars/1/original.png"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "1", "original.png"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Повторное удаление не считается ошибкой
+	assert.NoError(t, store.Delete(ctx, "avatars/1/original.png"))
+}
+
+func TestLocalStore_RejectsKeysOutsideDir(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "a/../../b", ".."} {
+		assert.ErrorIs(t, store.Put(context.Background(), key, strings.NewReader("x"), ""), ErrInvalidKey, key)
+	}
+}
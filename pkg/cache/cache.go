@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store хранит закодированные значения с ограниченным временем жизни
+type Store interface {
+	// Get возвращает значение по ключу, ok = false, если его нет или срок жизни истек
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set сохраняет значение на ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete удаляет значение, отсутствие ключа ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_EvictionAndTTL(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(2)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	assert.NoError(t, store.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Обращение к "a" делает вытесняемой запись "b"
+	_, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.NoError(t, store.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	value, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	// Просроченные записи не отдаются
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Delete(ctx, "a"))
+	assert.Equal(t, 0, store.Len())
+}
@@ -0,0 +1,156 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Manager владеет компонентами приложения (HTTP-сервер, пул БД, фоновые процессы)
+// и останавливает их в порядке, обратном регистрации.
+type Manager struct {
+	drainPeriod     time.Duration
+	shutdownTimeout time.Duration
+
+	mu         sync.Mutex
+	components []component
+	ready      atomic.Bool
+	errCh      chan error
+}
+
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// New создает менеджер жизненного цикла.
+// drainPeriod - сколько ждать после перевода readiness в состояние "не готов" до остановки компонентов,
+// shutdownTimeout - общее время на остановку всех компонентов.
+func New(drainPeriod, shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		drainPeriod:     drainPeriod,
+		shutdownTimeout: shutdownTimeout,
+		errCh:           make(chan error, 1),
+	}
+}
+
+// Ready сообщает, готово ли приложение принимать трафик
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// OnStop регистрирует ресурс, который нужно освободить при остановке
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// Serve запускает блокирующий процесс вроде HTTP-сервера.
+// При остановке вызывается shutdown, после чего менеджер дожидается завершения serve.
+func (m *Manager) Serve(name string, serve func() error, shutdown func(ctx context.Context) error) {
+	done := make(chan struct{})
+	var stopping atomic.Bool
+
+	go func() {
+		defer close(done)
+		if err := serve(); err != nil && !stopping.Load() {
+			m.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+
+	m.OnStop(name, func(ctx context.Context) error {
+		stopping.Store(true)
+		err := shutdown(ctx)
+		return errors.Join(err, wait(ctx, done))
+	})
+}
+
+// Go запускает фоновый процесс, который должен завершиться после отмены ctx
+func (m *Manager) Go(name string, run func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		if err := run(ctx); err != nil && ctx.Err() == nil {
+			m.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+
+	m.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		return wait(stopCtx, done)
+	})
+}
+
+// Wait помечает приложение готовым и блокируется до отмены ctx (обычно по сигналу)
+// или до ошибки одного из процессов, после чего останавливает все компоненты.
+func (m *Manager) Wait(ctx context.Context) error {
+	m.ready.Store(true)
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutdown requested")
+	case runErr = <-m.errCh:
+		logger.Errorf("Component failed, shutting down: %v", runErr)
+	}
+
+	return errors.Join(runErr, m.Shutdown())
+}
+
+// Shutdown переводит readiness в состояние "не готов", выжидает drainPeriod,
+// чтобы балансировщик перестал присылать запросы, и останавливает компоненты в обратном порядке.
+// Если приложение еще не было готово, ожидание пропускается.
+func (m *Manager) Shutdown() error {
+	wasReady := m.ready.Swap(false)
+	if wasReady && m.drainPeriod > 0 {
+		logger.Infof("Readiness is failing, draining for %s", m.drainPeriod)
+		time.Sleep(m.drainPeriod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	m.mu.Lock()
+	components := append([]component{}, m.components...)
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		logger.Infof("Stopping %s", c.name)
+		if err := c.stop(ctx); err != nil {
+			logger.Errorf("Failed to stop %s: %v", c.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fail сообщает об ошибке процесса, учитывается только первая
+func (m *Manager) fail(err error) {
+	select {
+	case m.errCh <- err:
+	default:
+		logger.Error(err)
+	}
+}
+
+// wait дожидается завершения процесса в пределах ctx
+func wait(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,67 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_StopsInReverseOrder(t *testing.T) {
+	m := New(0, time.Second)
+
+	var stopped []string
+	m.OnStop("database", func(ctx context.Context) error {
+		stopped = append(stopped, "database")
+		return nil
+	})
+	m.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped = append(stopped, "worker")
+		return nil
+	})
+	m.OnStop("http server", func(ctx context.Context) error {
+		stopped = append(stopped, "http server")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, m.Wait(ctx))
+	assert.Equal(t, []string{"http server", "worker", "database"}, stopped)
+	assert.False(t, m.Ready())
+}
+
+func TestManager_ComponentFailureTriggersShutdown(t *testing.T) {
+	m := New(0, time.Second)
+
+	stopped := false
+	m.OnStop("database", func(ctx context.Context) error {
+		stopped = true
+		return nil
+	})
+	m.Serve("http server", func() error {
+		return errors.New("address already in use")
+	}, func(ctx context.Context) error {
+		return nil
+	})
+
+	err := m.Wait(context.Background())
+	assert.ErrorContains(t, err, "http server: address already in use")
+	assert.True(t, stopped)
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	m := New(0, 10*time.Millisecond)
+
+	m.Go("stuck worker", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	err := m.Shutdown()
+	assert.ErrorContains(t, err, "stuck worker: did not stop in time")
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// Общий уровень логирования, его можно менять без перезапуска
+	baseLevel atomic.Uint32
+
+	// Уровень, к которому возвращается временное переопределение
+	defaultLevel logrus.Level
+
+	// Временное переопределение уровня
+	overrideTimer *time.Timer
+	overrideUntil time.Time
+)
+
+// LevelState описывает текущее состояние уровня логирования
+type LevelState struct {
+	Level         logrus.Level
+	DefaultLevel  logrus.Level
+	OverrideUntil time.Time // нулевое значение - переопределения нет
+}
+
+// Level возвращает текущий общий уровень логирования.
+func Level() logrus.Level {
+	return logrus.Level(baseLevel.Load())
+}
+
+// CurrentLevelState возвращает текущий уровень и информацию о временном переопределении.
+func CurrentLevelState() LevelState {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return LevelState{
+		Level:         Level(),
+		DefaultLevel:  defaultLevel,
+		OverrideUntil: overrideUntil,
+	}
+}
+
+// SetLevel меняет общий уровень логирования без перезапуска и отменяет временное переопределение.
+// Приемники с явно заданным уровнем продолжают использовать свой уровень.
+func SetLevel(level logrus.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	setLevelLocked(level)
+}
+
+// OverrideLevel временно меняет общий уровень логирования.
+// По истечении duration уровень возвращается к значению, заданному через SetLevel или конфигурацию.
+func OverrideLevel(level logrus.Level, duration time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cancelOverrideLocked()
+
+	baseLevel.Store(uint32(level))
+	applyLevelLocked()
+
+	overrideUntil = time.Now().Add(duration)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Переопределение могло быть отменено или заменено новым
+		if overrideTimer != timer {
+			return
+		}
+		overrideTimer = nil
+		overrideUntil = time.Time{}
+
+		baseLevel.Store(uint32(defaultLevel))
+		applyLevelLocked()
+		logrus.Infof("Временный уровень логирования истек, восстановлен уровень %s", defaultLevel)
+	})
+	overrideTimer = timer
+}
+
+// setDefaultLevel меняет уровень, к которому возвращается временное переопределение.
+// Без переопределения уровень применяется сразу.
+func setDefaultLevel(level logrus.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultLevel = level
+	if overrideTimer == nil {
+		baseLevel.Store(uint32(level))
+		applyLevelLocked()
+	}
+}
+
+func setLevelLocked(level logrus.Level) {
+	cancelOverrideLocked()
+
+	defaultLevel = level
+	baseLevel.Store(uint32(level))
+	applyLevelLocked()
+}
+
+func cancelOverrideLocked() {
+	if overrideTimer != nil {
+		overrideTimer.Stop()
+		overrideTimer = nil
+	}
+	overrideUntil = time.Time{}
+}
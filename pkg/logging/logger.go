@@ -1,54 +1,194 @@
 package logging
 
 import (
+	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"simple_crud_go/configs"
 )
 
+var (
+	// Текущие приемники логов и фоновая ротация файла
+	mu           sync.Mutex
+	activeSinks  []*sink
+	stopRotation chan struct{}
+)
+
 // SetupLogger настраивает глобальный логгер logrus в соответствии с конфигурацией.
+// Повторный вызов закрывает ранее открытые приемники и применяет новую конфигурацию.
 func SetupLogger(cfg *configs.LoggerConfig) {
-	// Устанавливаем уровень логирования
-	level, err := logrus.ParseLevel(cfg.Level)
+	replaceSinks(cfg)
+	SetLevel(parseLevel(cfg.Level))
+}
+
+// ApplyConfig применяет изменения конфигурации логирования при перезагрузке.
+// Приемники пересоздаются, только если изменились их настройки. Новый уровень становится уровнем
+// по умолчанию, а действующее временное переопределение уровня сохраняется до своего истечения.
+func ApplyConfig(prev, next *configs.LoggerConfig) {
+	if sinksChanged(prev, next) {
+		replaceSinks(next)
+	}
+	if prev.Level != next.Level {
+		setDefaultLevel(parseLevel(next.Level))
+	}
+}
+
+// sinksChanged сообщает, отличаются ли настройки приемников без учета общего уровня
+func sinksChanged(prev, next *configs.LoggerConfig) bool {
+	a, b := *prev, *next
+	a.Level, b.Level = "", ""
+	return a != b
+}
+
+func parseLevel(value string) logrus.Level {
+	level, err := logrus.ParseLevel(value)
 	if err != nil {
 		// Если уровень не распознан, выводим предупреждение и используем уровень по умолчанию
-		logrus.Warnf("Не удалось установить уровень логирования '%s', используется уровень по умолчанию: info", cfg.Level)
-		level = logrus.InfoLevel
+		logrus.Warnf("Не удалось установить уровень логирования '%s', используется уровень по умолчанию: info", value)
+		return logrus.InfoLevel
+	}
+	return level
+}
+
+// replaceSinks открывает приемники по конфигурации и закрывает ранее открытые, уровень не меняется
+func replaceSinks(cfg *configs.LoggerConfig) {
+	var sinks []*sink
+
+	// Устанавливаем вывод логов в файл с ротацией
+	var rotator *lumberjack.Logger
+	if cfg.OutputFile != "" {
+		if err := checkWritable(cfg.OutputFile); err != nil {
+			// В случае ошибки открытия файла, выводим предупреждение и используем консоль
+			logrus.Warnf("Не удалось записать логи в файл '%s', используется вывод в консоль: %v", cfg.OutputFile, err)
+		} else {
+			rotator = &lumberjack.Logger{
+				Filename:   cfg.OutputFile,
+				MaxSize:    cfg.Rotation.MaxSizeMB,
+				MaxAge:     cfg.Rotation.MaxAgeDays,
+				MaxBackups: cfg.Rotation.MaxBackups,
+				Compress:   cfg.Rotation.Compress,
+				LocalTime:  true,
+			}
+			sinks = append(sinks, newSink(rotator, rotator, cfg.File, cfg.Format))
+		}
+	}
+
+	// Консоль используется, если она включена явно или если файл не задан
+	if cfg.Console.Enabled || rotator == nil {
+		sinks = append(sinks, newSink(os.Stdout, nil, cfg.Console.LogSinkConfig, cfg.Format))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Сам logrus ничего не пишет, записи раздаются приемникам через хуки.
+	// Новые хуки подключаются до закрытия прежних приемников, чтобы запись в это время не открыла
+	// закрываемый файл заново
+	hooks := make(logrus.LevelHooks)
+	for _, s := range sinks {
+		hooks.Add(s)
+	}
+	logrus.StandardLogger().ReplaceHooks(hooks)
+	logrus.SetOutput(io.Discard)
+	logrus.SetFormatter(discardFormatter{})
+
+	closeLocked()
+
+	activeSinks = sinks
+	applyLevelLocked()
+
+	if rotator != nil && cfg.Rotation.Interval > 0 {
+		stopRotation = make(chan struct{})
+		go rotateEvery(rotator, cfg.Rotation.Interval, stopRotation)
+	}
+}
+
+// Close останавливает ротацию и закрывает файлы логов.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return closeLocked()
+}
+
+func closeLocked() error {
+	if stopRotation != nil {
+		close(stopRotation)
+		stopRotation = nil
+	}
+
+	var firstErr error
+	for _, s := range activeSinks {
+		if err := s.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	activeSinks = nil
+	return firstErr
+}
+
+// applyLevelLocked выставляет logrus самый подробный из уровней приемников,
+// чтобы записи доходили до хуков, а фильтрация выполнялась в каждом приемнике.
+func applyLevelLocked() {
+	level := Level()
+	for _, s := range activeSinks {
+		if s.level != nil && *s.level > level {
+			level = *s.level
+		}
 	}
 	logrus.SetLevel(level)
+}
+
+// rotateEvery принудительно ротирует файл логов с заданным интервалом
+func rotateEvery(rotator *lumberjack.Logger, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := rotator.Rotate(); err != nil {
+				logrus.Errorf("Не удалось ротировать файл логов: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// checkWritable проверяет, что файл логов можно открыть на запись
+func checkWritable(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
 
-	// Устанавливаем формат вывода
-	switch cfg.Format {
+// newFormatter возвращает форматтер для указанного формата вывода
+func newFormatter(format string) logrus.Formatter {
+	switch format {
 	case "json":
-		logrus.SetFormatter(&logrus.JSONFormatter{
+		return &logrus.JSONFormatter{
 			TimestampFormat: time.RFC3339,
-		})
+		}
 	default:
 		// По умолчанию вывод в текстовом формате с полными временными метками
-		logrus.SetFormatter(&logrus.TextFormatter{
+		return &logrus.TextFormatter{
 			TimestampFormat: time.RFC3339,
 			FullTimestamp:   true,
-		})
-	}
-
-	// Устанавливаем вывод логов (файл или консоль)
-	if cfg.OutputFile != "" {
-		// Пытаемся открыть файл для записи
-		file, err := os.OpenFile(cfg.OutputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			// В случае ошибки открытия файла, выводим предупреждение и используем консоль
-			logrus.Warnf("Не удалось записать логи в файл '%s', используется вывод в консоль: %v", cfg.OutputFile, err)
-			logrus.SetOutput(os.Stdout)
-		} else {
-			// Если файл открыт успешно, устанавливаем его как вывод для логов
-			logrus.SetOutput(file)
-			// Закрытие файла нужно организовать в вызывающем коде или через хук
 		}
-	} else {
-		// Если путь к файлу не задан, выводим логи в консоль
-		logrus.SetOutput(os.Stdout)
 	}
 }
+
+// discardFormatter используется для основного вывода logrus, который отключен
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"simple_crud_go/configs"
+)
+
+func newEntry(level logrus.Level) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = level
+	entry.Message = "test message"
+	return entry
+}
+
+func TestSink_FollowsRuntimeLevel(t *testing.T) {
+	var buf bytes.Buffer
+	s := newSink(&buf, nil, configs.LogSinkConfig{}, "text")
+
+	SetLevel(logrus.InfoLevel)
+	assert.NoError(t, s.Fire(newEntry(logrus.DebugLevel)))
+	assert.Empty(t, buf.String())
+
+	SetLevel(logrus.DebugLevel)
+	assert.NoError(t, s.Fire(newEntry(logrus.DebugLevel)))
+	assert.Contains(t, buf.String(), "test message")
+}
+
+func TestSink_OwnLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	s := newSink(&buf, nil, configs.LogSinkConfig{Level: "error", Format: "json"}, "text")
+
+	SetLevel(logrus.DebugLevel)
+	assert.NoError(t, s.Fire(newEntry(logrus.WarnLevel)))
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, s.Fire(newEntry(logrus.ErrorLevel)))
+	assert.Contains(t, buf.String(), `"msg":"test message"`)
+}
+
+// nopCloser закрывает буфер, не освобождая его
+type nopCloser struct{ closed bool }
+
+func (c *nopCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSink_DropsEntriesAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	closer := &nopCloser{}
+	s := newSink(&buf, closer, configs.LogSinkConfig{}, "text")
+
+	SetLevel(logrus.InfoLevel)
+	assert.NoError(t, s.close())
+	assert.True(t, closer.closed)
+
+	// Запись по устаревшей копии хуков не доходит до закрытого файла
+	assert.NoError(t, s.Fire(newEntry(logrus.InfoLevel)))
+	assert.Empty(t, buf.String())
+}
+
+func TestOverrideLevel_Reverts(t *testing.T) {
+	SetLevel(logrus.InfoLevel)
+
+	OverrideLevel(logrus.DebugLevel, 20*time.Millisecond)
+	state := CurrentLevelState()
+	assert.Equal(t, logrus.DebugLevel, state.Level)
+	assert.Equal(t, logrus.InfoLevel, state.DefaultLevel)
+	assert.False(t, state.OverrideUntil.IsZero())
+
+	assert.Eventually(t, func() bool {
+		return Level() == logrus.InfoLevel
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, CurrentLevelState().OverrideUntil.IsZero())
+}
+
+func TestApplyConfig_KeepsOverride(t *testing.T) {
+	prev := configs.LoggerConfig{Level: "info", Format: "text"}
+	SetupLogger(&prev)
+	t.Cleanup(func() { Close() })
+
+	OverrideLevel(logrus.DebugLevel, 50*time.Millisecond)
+
+	next := prev
+	next.Level = "warn"
+	ApplyConfig(&prev, &next)
+
+	state := CurrentLevelState()
+	assert.Equal(t, logrus.DebugLevel, state.Level)
+	assert.Equal(t, logrus.WarnLevel, state.DefaultLevel)
+	assert.False(t, state.OverrideUntil.IsZero())
+
+	assert.Eventually(t, func() bool {
+		return Level() == logrus.WarnLevel
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"simple_crud_go/configs"
+)
+
+// sink - приемник логов со своим уровнем и форматом, подключается к logrus как хук
+type sink struct {
+	mu        sync.Mutex
+	writer    io.Writer
+	closer    io.Closer
+	formatter logrus.Formatter
+	level     *logrus.Level // nil - используется общий уровень
+	closed    bool
+}
+
+func newSink(writer io.Writer, closer io.Closer, cfg configs.LogSinkConfig, defaultFormat string) *sink {
+	s := &sink{writer: writer, closer: closer}
+
+	format := cfg.Format
+	if format == "" {
+		format = defaultFormat
+	}
+	s.formatter = newFormatter(format)
+
+	if cfg.Level != "" {
+		level, err := logrus.ParseLevel(cfg.Level)
+		if err != nil {
+			logrus.Warnf("Не удалось установить уровень логирования приемника '%s', используется общий уровень", cfg.Level)
+		} else {
+			s.level = &level
+		}
+	}
+
+	return s
+}
+
+func (s *sink) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (s *sink) Fire(entry *logrus.Entry) error {
+	level := Level()
+	if s.level != nil {
+		level = *s.level
+	}
+	if entry.Level > level {
+		return nil
+	}
+
+	b, err := s.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// logrus вызывает хуки по копии их набора, поэтому запись может прийти в уже замененный приемник
+	if s.closed {
+		return nil
+	}
+
+	// Ошибку записи logrus выведет в stderr
+	_, err = s.writer.Write(b)
+	return err
+}
+
+func (s *sink) close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return s.closer.Close()
+}
@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Refill(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	rule := Rule{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(ctx, "key", rule)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, _ := store.Take(ctx, "key", rule)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Через полторы секунды в ведре появляется один токен
+	now = now.Add(1500 * time.Millisecond)
+	result, _ = store.Take(ctx, "key", rule)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Неиспользуемые ведра удаляются
+	now = now.Add(time.Hour)
+	assert.NoError(t, store.Cleanup(ctx, time.Minute))
+	assert.Empty(t, store.buckets)
+}
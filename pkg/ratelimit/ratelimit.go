@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Rule - параметры ведра токенов: скорость пополнения и емкость
+type Rule struct {
+	Rate  float64 // Токенов в секунду
+	Burst int     // Емкость ведра
+}
+
+// Result - результат попытки забрать токен
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // Через сколько появится следующий токен, если запрос отклонен
+	ResetAfter time.Duration // Через сколько ведро заполнится полностью
+}
+
+// Store хранит состояние ведер токенов
+type Store interface {
+	// Take забирает токен из ведра key, если он есть
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+	// Cleanup удаляет ведра, которые не использовались дольше idle
+	Cleanup(ctx context.Context, idle time.Duration) error
+}
+
+// NewResult формирует результат по количеству токенов, оставшихся после попытки
+func NewResult(rule Rule, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rule.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if rule.Rate > 0 {
+		result.ResetAfter = secondsToDuration((float64(rule.Burst) - tokens) / rule.Rate)
+		if !allowed {
+			result.RetryAfter = secondsToDuration((1 - tokens) / rule.Rate)
+		}
+	}
+	return result
+}
+
+// refill возвращает количество токенов после пополнения за прошедшее время
+func refill(rule Rule, tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(rule.Burst), tokens+elapsed.Seconds()*rule.Rate)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RunCleanup периодически удаляет неиспользуемые ведра, пока не будет отменен ctx
+func RunCleanup(ctx context.Context, store Store, interval, idle time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := store.Cleanup(ctx, idle); err != nil && ctx.Err() == nil {
+				logger.Errorf("Rate limit cleanup failed: %v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -2,51 +2,102 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
-	"time"
 
 	logger "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"simple_crud_go/configs"
 )
 
-func SetupAndRunServer(cfg *configs.ServerConfig, handler http.Handler) {
+// Server - HTTP-сервер приложения с опциональным TLS
+type Server struct {
+	httpServer *http.Server
+	reloader   *certReloader
+}
+
+// NewServer создает HTTP-сервер по конфигурации
+func NewServer(cfg *configs.ServerConfig, handler http.Handler) (*Server, error) {
+	// HTTP/2 без TLS для работы за прокси
+	if cfg.H2C && !cfg.TLS.Enabled() {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
 	// Создаем HTTP-сервер
-	server := &http.Server{
-		Addr:           cfg.Host + ":" + strconv.Itoa(cfg.Port),
-		Handler:        handler,
-		ReadTimeout:    cfg.ReadTimeout,
-		WriteTimeout:   cfg.WriteTimeout,
-		MaxHeaderBytes: cfg.MaxHeaderBytes,
-	}
-
-	// Канал для обработки сигналов завершения работы
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	// Запускаем сервер в отдельной горутине
-	go func() {
-		logger.Infof("Starting server on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("Could not start server: %v", err)
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:           cfg.Host + ":" + strconv.Itoa(cfg.Port),
+			Handler:        handler,
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+		},
+	}
+
+	// Настраиваем TLS, сертификаты перечитываются при изменении файлов
+	if cfg.TLS.Enabled() {
+		tlsConfig, reloader, err := newTLSConfig(&cfg.TLS)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		s.httpServer.TLSConfig = tlsConfig
+		s.reloader = reloader
+	}
 
-	// Ожидаем сигнал завершения
-	<-stop
-	logger.Info("Shutting down server...")
+	return s, nil
+}
 
-	// Контекст для завершения активных соединений
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// ListenAndServe принимает соединения до остановки сервера, штатная остановка ошибкой не считается
+func (s *Server) ListenAndServe() error {
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		logger.Infof("Starting HTTPS server on %s", s.httpServer.Addr)
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		logger.Infof("Starting server on %s", s.httpServer.Addr)
+		err = s.httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatalf("Server forced to shutdown: %v", err)
+// Shutdown дожидается завершения активных запросов в пределах ctx,
+// по истечении ctx оставшиеся соединения закрываются принудительно
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.httpServer.Close()
+	}
+	if s.reloader != nil {
+		s.reloader.Close()
+	}
+	if err == nil {
+		logger.Info("Server gracefully stopped")
 	}
+	return err
+}
+
+// WatchReloadSignal вызывает onReload на каждый SIGHUP, пока не будет отменен ctx
+func WatchReloadSignal(ctx context.Context, onReload func()) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
-	logger.Info("Server gracefully stopped")
+	for {
+		select {
+		case <-hup:
+			logger.Info("Received SIGHUP, reloading configuration")
+			onReload()
+		case <-ctx.Done():
+			return nil
+		}
+	}
 }
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	logger "github.com/sirupsen/logrus"
+
+	"simple_crud_go/configs"
+)
+
+// Наборы шифров для политики intermediate (TLS 1.2, только ECDHE и AEAD)
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                tls.NoClientCert,
+	"none":            tls.NoClientCert,
+	"request":         tls.RequestClientCert,
+	"verify_if_given": tls.VerifyClientCertIfGiven,
+	"require":         tls.RequireAndVerifyClientCert,
+}
+
+// certReloader хранит сертификат сервера и CA клиентов и перечитывает их при изменении файлов
+type certReloader struct {
+	cfg     *configs.TLSConfig
+	base    *tls.Config
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	caPool  *x509.CertPool
+	watcher *fsnotify.Watcher
+}
+
+// newTLSConfig строит tls.Config по конфигурации и запускает отслеживание файлов сертификатов
+func newTLSConfig(cfg *configs.TLSConfig) (*tls.Config, *certReloader, error) {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuthTypes[cfg.ClientAuth],
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if cfg.MinVersion == "1.3" {
+		base.MinVersion = tls.VersionTLS13
+	}
+	switch cfg.CipherPolicy {
+	case "intermediate":
+		base.CipherSuites = intermediateCipherSuites
+	case "modern":
+		base.MinVersion = tls.VersionTLS13
+	}
+
+	if base.ClientAuth >= tls.VerifyClientCertIfGiven && cfg.ClientCAFile == "" {
+		return nil, nil, errors.New("tls: client_ca_file is required to verify client certificates")
+	}
+
+	r := &certReloader{cfg: cfg, base: base}
+	if err := r.reload(); err != nil {
+		return nil, nil, err
+	}
+	if err := r.watch(); err != nil {
+		return nil, nil, err
+	}
+
+	base.GetConfigForClient = r.getConfigForClient
+	return base, r, nil
+}
+
+// reload перечитывает сертификат, ключ и CA клиентов
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load certificate: %w", err)
+	}
+
+	var caPool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read client CA: %w", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.caPool = caPool
+	r.mu.Unlock()
+	return nil
+}
+
+// watch следит за каталогами файлов: при обновлении секретов файлы обычно подменяются целиком
+func (r *certReloader) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dirs := map[string]struct{}{}
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = struct{}{}
+		}
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("tls: watch %s: %w", dir, err)
+		}
+	}
+	r.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := r.reload(); err != nil {
+					// Файлы могут обновляться по одному, действует предыдущий сертификат
+					logger.Warnf("TLS certificate reload failed, keeping the previous one: %v", err)
+					continue
+				}
+				logger.Infof("TLS certificate reloaded after change of %s", event.Name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorf("TLS certificate watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg := r.base.Clone()
+	cfg.GetConfigForClient = nil
+	cfg.Certificates = []tls.Certificate{*r.cert}
+	cfg.ClientCAs = r.caPool
+	return cfg, nil
+}
+
+// Close останавливает отслеживание файлов сертификатов
+func (r *certReloader) Close() error {
+	if r.watcher == nil {
+		return nil
+	}
+	return r.watcher.Close()
+}
@@ -1,20 +1,93 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
-
-// HashPassword принимает строку пароля и возвращает его хэш.
-func HashPassword(password string) (string, error) {
-	// Генерируем хэш пароля с использованием bcrypt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+import (
+	"errors"
+	"strings"
+
+	"simple_crud_go/configs"
+)
+
+// ErrUnknownHashFormat - хэш создан неизвестным алгоритмом
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher хэширует и проверяет пароли.
+// Хэши самоописываемые: в них записаны алгоритм и параметры, поэтому проверка выбирает нужный алгоритм сама.
+type PasswordHasher interface {
+	// Hash возвращает хэш пароля
+	Hash(password string) (string, error)
+	// Verify сравнивает пароль с хэшем
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash сообщает, что хэш создан другим алгоритмом или с устаревшими параметрами
+	NeedsRehash(encoded string) bool
+}
+
+// algorithmHasher - реализация конкретного алгоритма
+type algorithmHasher interface {
+	PasswordHasher
+	// Matches сообщает, создан ли хэш этим алгоритмом
+	Matches(encoded string) bool
+}
+
+// multiHasher хэширует предпочтительным алгоритмом и проверяет хэши любого из поддерживаемых
+type multiHasher struct {
+	preferred  algorithmHasher
+	algorithms []algorithmHasher
+}
+
+// NewPasswordHasher возвращает хэшер, создающий хэши алгоритмом из конфигурации
+// и умеющий проверять хэши всех поддерживаемых алгоритмов
+func NewPasswordHasher(cfg *configs.PasswordHashingConfig) (PasswordHasher, error) {
+	bcryptHasher := NewBcryptHasher(cfg.BcryptCost)
+	argon2Hasher := &Argon2idHasher{
+		Memory:      cfg.Argon2.Memory,
+		Iterations:  cfg.Argon2.Iterations,
+		Parallelism: cfg.Argon2.Parallelism,
+		SaltLength:  cfg.Argon2.SaltLength,
+		KeyLength:   cfg.Argon2.KeyLength,
+	}
+	h := &multiHasher{algorithms: []algorithmHasher{bcryptHasher, argon2Hasher}}
+
+	switch cfg.Algorithm {
+	case "bcrypt":
+		h.preferred = bcryptHasher
+	case "argon2id":
+		h.preferred = argon2Hasher
+	default:
+		return nil, errors.New("unknown password hashing algorithm: " + cfg.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *multiHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *multiHasher) Verify(password, encoded string) (bool, error) {
+	algorithm := h.detect(encoded)
+	if algorithm == nil {
+		return false, ErrUnknownHashFormat
+	}
+	return algorithm.Verify(password, encoded)
+}
+
+func (h *multiHasher) NeedsRehash(encoded string) bool {
+	if !h.preferred.Matches(encoded) {
+		return true
+	}
+	return h.preferred.NeedsRehash(encoded)
+}
+
+func (h *multiHasher) detect(encoded string) algorithmHasher {
+	for _, algorithm := range h.algorithms {
+		if algorithm.Matches(encoded) {
+			return algorithm
+		}
 	}
-	return string(hashedPassword), nil
+	return nil
 }
 
-// CheckPassword сравнивает предоставленный пароль с хэшированным значением.
-func CheckPassword(password, hashedPassword string) bool {
-	// Проверяем пароль
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+// splitHash разбивает хэш формата $alg$...$... на части без ведущего разделителя
+func splitHash(encoded string) []string {
+	return strings.Split(strings.TrimPrefix(encoded, "$"), "$")
 }
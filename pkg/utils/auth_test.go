@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"simple_crud_go/configs"
+)
+
+func testHashingConfig(algorithm string) *configs.PasswordHashingConfig {
+	return &configs.PasswordHashingConfig{
+		Algorithm:  algorithm,
+		BcryptCost: 4,
+		Argon2: configs.Argon2Config{
+			Memory:      8 * 1024,
+			Iterations:  1,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+	}
+}
+
+func TestPasswordHasher_HashAndVerify(t *testing.T) {
+	for _, algorithm := range []string{"bcrypt", "argon2id"} {
+		t.Run(algorithm, func(t *testing.T) {
+			hasher, err := NewPasswordHasher(testHashingConfig(algorithm))
+			require.NoError(t, err)
+
+			hash, err := hasher.Hash("Secret123")
+			require.NoError(t, err)
+			assert.False(t, hasher.NeedsRehash(hash))
+
+			ok, err := hasher.Verify("Secret123", hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify("Secret124", hash)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestPasswordHasher_VerifiesOtherAlgorithm(t *testing.T) {
+	bcryptHasher, err := NewPasswordHasher(testHashingConfig("bcrypt"))
+	require.NoError(t, err)
+	argon2Hasher, err := NewPasswordHasher(testHashingConfig("argon2id"))
+	require.NoError(t, err)
+
+	hash, err := bcryptHasher.Hash("Secret123")
+	require.NoError(t, err)
+
+	// Хэш bcrypt проверяется, но требует перехэширования в argon2id
+	ok, err := argon2Hasher.Verify("Secret123", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, argon2Hasher.NeedsRehash(hash))
+}
+
+func TestPasswordHasher_NeedsRehashOnChangedParams(t *testing.T) {
+	cfg := testHashingConfig("argon2id")
+	hasher, err := NewPasswordHasher(cfg)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("Secret123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$"))
+
+	cfg.Argon2.Iterations = 2
+	upgraded, err := NewPasswordHasher(cfg)
+	require.NoError(t, err)
+	assert.True(t, upgraded.NeedsRehash(hash))
+
+	ok, err := upgraded.Verify("Secret123", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPasswordHasher_UnknownFormat(t *testing.T) {
+	hasher, err := NewPasswordHasher(testHashingConfig("argon2id"))
+	require.NoError(t, err)
+
+	_, err = hasher.Verify("Secret123", "plaintext")
+	assert.ErrorIs(t, err, ErrUnknownHashFormat)
+}
+
+func TestPasswordHasher_RejectsDegenerateArgon2Hash(t *testing.T) {
+	hasher, err := NewPasswordHasher(testHashingConfig("argon2id"))
+	require.NoError(t, err)
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=8192,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$",
+		"$argon2id$v=19$m=8192,t=1,p=1$$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=8192,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=8192,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+	} {
+		ok, err := hasher.Verify("anything", hash)
+		assert.ErrorIs(t, err, ErrUnknownHashFormat, hash)
+		assert.False(t, ok)
+		assert.True(t, hasher.NeedsRehash(hash))
+	}
+}